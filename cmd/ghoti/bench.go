@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// benchOps maps the operations the benchmark can run to the call they make
+var benchOps = map[string]func(ctx context.Context, client *ghoti.Client, slot int, data string) error{
+	"read": func(ctx context.Context, client *ghoti.Client, slot int, _ string) error {
+		_, err := client.ReadContext(ctx, slot)
+		return err
+	},
+	"write": func(ctx context.Context, client *ghoti.Client, slot int, data string) error {
+		return client.WriteContext(ctx, slot, data)
+	},
+	"broadcast": func(ctx context.Context, client *ghoti.Client, slot int, data string) error {
+		_, _, _, err := client.BroadcastContext(ctx, slot, data)
+		return err
+	},
+	"incr": func(ctx context.Context, client *ghoti.Client, slot int, _ string) error {
+		_, err := client.AtomicCounter(slot).AddContext(ctx, 1)
+		return err
+	},
+	"take": func(ctx context.Context, client *ghoti.Client, slot int, _ string) error {
+		_, err := client.TokenBucket(slot).GetTokensContext(ctx)
+		return err
+	},
+	"acquire": func(ctx context.Context, client *ghoti.Client, slot int, _ string) error {
+		_, err := client.LeakyBucket(slot).TryAcquireContext(ctx)
+		return err
+	},
+}
+
+// slotRange is an inclusive range of slots
+type slotRange struct {
+	from, to int
+}
+
+// parseSlotRange parses a range such as 100-199, or a single slot
+func parseSlotRange(arg string) (slotRange, error) {
+	from, to, found := strings.Cut(arg, "-")
+	if !found {
+		to = from
+	}
+
+	first, err := parseSlot(from)
+	if err != nil {
+		return slotRange{}, err
+	}
+	last, err := parseSlot(to)
+	if err != nil {
+		return slotRange{}, err
+	}
+	if last < first {
+		return slotRange{}, fmt.Errorf("invalid slot range %q: the end is before the start", arg)
+	}
+
+	return slotRange{from: first, to: last}, nil
+}
+
+// pick returns a random slot of the range
+func (r slotRange) pick() int {
+	return r.from + rand.IntN(r.to-r.from+1)
+}
+
+// benchMix is an operation of the mix with its weight and slots
+type benchMix struct {
+	op     string
+	weight int
+	slots  slotRange
+}
+
+// benchConfig holds the flags of the bench command
+type benchConfig struct {
+	clients  int
+	duration time.Duration
+	ops      int64
+	mix      []benchMix
+	data     string
+}
+
+// parseBenchFlags parses the flags of the bench command
+func parseBenchFlags(args []string) (*benchConfig, error) {
+	c := &benchConfig{}
+	var slots, mix string
+	var size int
+
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.IntVar(&c.clients, "clients", 10, "number of clients sending requests")
+	flags.DurationVar(&c.duration, "duration", 10*time.Second, "how long to run, 0 to run until -ops are sent")
+	flags.Int64Var(&c.ops, "ops", 0, "number of operations to send, 0 to run for -duration")
+	flags.StringVar(&slots, "slots", "0-99", "slots used by the operations without their own range")
+	flags.StringVar(&mix, "mix", "read=80,write=20", "weights of the operations: read, write, broadcast, incr, take and acquire")
+	flags.IntVar(&size, "size", 8, "length of the data written")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	if c.clients < 1 {
+		return nil, fmt.Errorf("invalid clients %d: must be at least 1", c.clients)
+	}
+	if c.duration < 0 || c.ops < 0 {
+		return nil, fmt.Errorf("duration and ops can't be negative")
+	}
+	if c.duration == 0 && c.ops == 0 {
+		return nil, fmt.Errorf("either duration or ops must be set")
+	}
+	if size < 0 || size > protocol.MaxDataLength {
+		return nil, fmt.Errorf("invalid size %d: must be between 0 and %d", size, protocol.MaxDataLength)
+	}
+	c.data = strings.Repeat("x", size)
+
+	defaultSlots, err := parseSlotRange(slots)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range strings.Split(mix, ",") {
+		m, err := parseBenchMix(strings.TrimSpace(entry), defaultSlots)
+		if err != nil {
+			return nil, err
+		}
+		if m.weight > 0 {
+			c.mix = append(c.mix, m)
+		}
+	}
+	if len(c.mix) == 0 {
+		return nil, fmt.Errorf("the mix has no operations")
+	}
+
+	return c, nil
+}
+
+// parseBenchMix parses an entry of the mix such as incr=10:200-209
+func parseBenchMix(entry string, slots slotRange) (benchMix, error) {
+	op, value, found := strings.Cut(entry, "=")
+	if !found {
+		return benchMix{}, fmt.Errorf("invalid mix %q: must be op=weight[:from-to]", entry)
+	}
+	if _, ok := benchOps[op]; !ok {
+		return benchMix{}, fmt.Errorf("invalid mix %q: unknown operation %q", entry, op)
+	}
+
+	weight, rangeArg, hasRange := strings.Cut(value, ":")
+	w, err := strconv.Atoi(weight)
+	if err != nil || w < 0 {
+		return benchMix{}, fmt.Errorf("invalid mix %q: weight must be a positive number", entry)
+	}
+
+	if hasRange {
+		slots, err = parseSlotRange(rangeArg)
+		if err != nil {
+			return benchMix{}, fmt.Errorf("invalid mix %q: %w", entry, err)
+		}
+	}
+
+	return benchMix{op: op, weight: w, slots: slots}, nil
+}
+
+// pick returns a random operation of the mix according to the weights
+func (c *benchConfig) pick() benchMix {
+	total := 0
+	for _, m := range c.mix {
+		total += m.weight
+	}
+
+	n := rand.IntN(total)
+	for _, m := range c.mix {
+		if n < m.weight {
+			return m
+		}
+		n -= m.weight
+	}
+	return c.mix[len(c.mix)-1]
+}
+
+// benchStats are the results of the operations sent by a client
+type benchStats struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	codes     map[string]int
+}
+
+func newBenchStats() *benchStats {
+	return &benchStats{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		codes:     make(map[string]int),
+	}
+}
+
+// add records the result of an operation
+func (s *benchStats) add(op string, latency time.Duration, err error) {
+	s.latencies[op] = append(s.latencies[op], latency)
+	if err != nil {
+		s.errors[op]++
+		s.codes[errorCode(err)]++
+	}
+}
+
+// merge adds the results of other
+func (s *benchStats) merge(other *benchStats) {
+	for op, latencies := range other.latencies {
+		s.latencies[op] = append(s.latencies[op], latencies...)
+	}
+	for op, n := range other.errors {
+		s.errors[op] += n
+	}
+	for code, n := range other.codes {
+		s.codes[code] += n
+	}
+}
+
+// clientErrors are the errors of the client reported by name in the breakdown
+var clientErrors = []error{
+	ghoti.ErrTimeout,
+	ghoti.ErrCanceled,
+	ghoti.ErrClosed,
+	ghoti.ErrConnectionLost,
+	ghoti.ErrReconnecting,
+	ghoti.ErrInvalidResponse,
+}
+
+// errorCode returns the key of an error in the breakdown, the code of the
+// errors sent by the server or the name of the errors of the client
+func errorCode(err error) string {
+	var ghotiErr *model.GhotiError
+	if errors.As(err, &ghotiErr) {
+		return ghotiErr.Code
+	}
+
+	for _, target := range clientErrors {
+		if errors.Is(err, target) {
+			return target.Error()
+		}
+	}
+
+	return "other"
+}
+
+// runBench sends the mix of operations from several clients and reports the
+// throughput and latencies
+func runBench(ctx context.Context, s *session, args []string) error {
+	c, _ := parseBenchFlags(args)
+
+	clients := []*ghoti.Client{s.client}
+	defer func() {
+		for _, client := range clients[1:] {
+			client.Close()
+		}
+	}()
+	for len(clients) < c.clients {
+		client, err := s.dial()
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+
+	if c.duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.duration)
+		defer cancel()
+	}
+
+	var sent atomic.Int64
+	results := make([]*benchStats, len(clients))
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i, client := range clients {
+		results[i] = newBenchStats()
+		wg.Add(1)
+		go func(stats *benchStats) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if c.ops > 0 && sent.Add(1) > c.ops {
+					return
+				}
+
+				m := c.pick()
+				opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+				opStart := time.Now()
+				err := benchOps[m.op](opCtx, client, m.slots.pick(), c.data)
+				latency := time.Since(opStart)
+				cancel()
+
+				// Operations interrupted by the end of the run don't count
+				if ctx.Err() != nil {
+					return
+				}
+				stats.add(m.op, latency, err)
+			}
+		}(results[i])
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	total := newBenchStats()
+	for _, stats := range results {
+		total.merge(stats)
+	}
+
+	report := newBenchReport(len(clients), elapsed, total)
+	s.out.result(report.String(), report)
+	return nil
+}
+
+// latencyReport holds the latency percentiles in milliseconds
+type latencyReport struct {
+	P50 float64 `json:"p50_ms"`
+	P90 float64 `json:"p90_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// newLatencyReport computes the percentiles of the latencies, sorting them
+func newLatencyReport(latencies []time.Duration) latencyReport {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	return latencyReport{
+		P50: milliseconds(percentile(latencies, 0.50)),
+		P90: milliseconds(percentile(latencies, 0.90)),
+		P99: milliseconds(percentile(latencies, 0.99)),
+		Max: milliseconds(percentile(latencies, 1)),
+	}
+}
+
+// percentile returns the latency below which the fraction p of the sorted
+// latencies fall
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// opReport holds the results of an operation
+type opReport struct {
+	Ops     int           `json:"ops"`
+	Errors  int           `json:"errors"`
+	Latency latencyReport `json:"latency"`
+}
+
+// benchReport is the result of the bench command
+type benchReport struct {
+	Clients    int                 `json:"clients"`
+	Duration   float64             `json:"duration_seconds"`
+	Ops        int                 `json:"ops"`
+	Errors     int                 `json:"errors"`
+	Throughput float64             `json:"ops_per_second"`
+	Latency    latencyReport       `json:"latency"`
+	Operations map[string]opReport `json:"operations"`
+	ErrorCodes map[string]int      `json:"errors_by_code"`
+}
+
+// newBenchReport summarizes the results of a run
+func newBenchReport(clients int, elapsed time.Duration, stats *benchStats) *benchReport {
+	report := &benchReport{
+		Clients:    clients,
+		Duration:   elapsed.Seconds(),
+		Operations: make(map[string]opReport),
+		ErrorCodes: stats.codes,
+	}
+
+	var all []time.Duration
+	for op, latencies := range stats.latencies {
+		all = append(all, latencies...)
+		report.Operations[op] = opReport{
+			Ops:     len(latencies),
+			Errors:  stats.errors[op],
+			Latency: newLatencyReport(latencies),
+		}
+		report.Errors += stats.errors[op]
+	}
+
+	report.Ops = len(all)
+	report.Latency = newLatencyReport(all)
+	if elapsed > 0 {
+		report.Throughput = float64(report.Ops) / elapsed.Seconds()
+	}
+
+	return report
+}
+
+// String formats the report as a table
+func (r *benchReport) String() string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%d ops in %.2fs with %d clients, %.1f ops/s, %d errors\n\n",
+		r.Ops, r.Duration, r.Clients, r.Throughput, r.Errors)
+
+	w := tabwriter.NewWriter(&text, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "op\tops\terrors\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+
+	ops := make([]string, 0, len(r.Operations))
+	for op := range r.Operations {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		report := r.Operations[op]
+		writeLatencyRow(w, op, report.Ops, report.Errors, report.Latency)
+	}
+	writeLatencyRow(w, "total", r.Ops, r.Errors, r.Latency)
+	w.Flush()
+
+	if len(r.ErrorCodes) > 0 {
+		codes := make([]string, 0, len(r.ErrorCodes))
+		for code := range r.ErrorCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		fmt.Fprintln(&text, "\nerrors by code:")
+		for _, code := range codes {
+			name := code
+			if len(code) == 3 {
+				name = code + " " + model.NewGhotiError(code).Message
+			}
+			fmt.Fprintf(&text, "  %s: %d\n", name, r.ErrorCodes[code])
+		}
+	}
+
+	return strings.TrimSuffix(text.String(), "\n")
+}
+
+// writeLatencyRow writes a row of the report table
+func writeLatencyRow(w io.Writer, op string, ops, failed int, latency latencyReport) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.3f\t%.3f\t%.3f\t%.3f\t\n",
+		op, ops, failed, latency.P50, latency.P90, latency.P99, latency.Max)
+}
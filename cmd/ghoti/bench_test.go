@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBench(t *testing.T) {
+	server := newServer(t)
+
+	code, stdout, stderr := runCommand(t, "-server", server.Addr(), "-o", "json",
+		"bench", "-clients", "3", "-duration", "0", "-ops", "300", "-mix", "read=2:1,incr=1:3,take=1:4")
+	require.Equal(t, 0, code, stderr)
+
+	var report benchReport
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+
+	assert.Equal(t, 3, report.Clients)
+	assert.Equal(t, 300, report.Ops)
+	assert.Equal(t, 300, report.Operations["read"].Ops+report.Operations["incr"].Ops+report.Operations["take"].Ops)
+	assert.Zero(t, report.Operations["read"].Errors)
+	assert.Greater(t, report.Throughput, 0.0)
+	assert.LessOrEqual(t, report.Latency.P50, report.Latency.P99)
+	assert.LessOrEqual(t, report.Latency.P99, report.Latency.Max)
+
+	// The bucket has a single token, every other take fails with code 008
+	assert.Equal(t, report.Operations["take"].Ops-1, report.Operations["take"].Errors)
+	assert.Equal(t, report.Errors, report.ErrorCodes["008"])
+
+	// Every increment went to the counter
+	assert.Equal(t, strconv.Itoa(10+report.Operations["incr"].Ops), server.Value(3))
+}
+
+func TestBenchText(t *testing.T) {
+	server := newServer(t)
+
+	code, stdout, stderr := runCommand(t, "-server", server.Addr(),
+		"bench", "-clients", "2", "-duration", "50ms", "-mix", "take=1:4")
+	require.Equal(t, 0, code, stderr)
+
+	assert.Contains(t, stdout, "with 2 clients")
+	assert.Contains(t, stdout, "take")
+	assert.Contains(t, stdout, "errors by code:\n  008 No tokens available: ")
+}
+
+func TestBenchFlags(t *testing.T) {
+	tests := map[string][]string{
+		"no clients":      {"-clients", "0"},
+		"no limit":        {"-duration", "0"},
+		"unknown op":      {"-mix", "delete=1"},
+		"invalid weight":  {"-mix", "read=x"},
+		"invalid range":   {"-mix", "read=1:20-10"},
+		"empty mix":       {"-mix", "read=0"},
+		"data too long":   {"-size", "37"},
+		"invalid slots":   {"-slots", "0-1000"},
+		"extra arguments": {"5"},
+	}
+
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseBenchFlags(args)
+			assert.Error(t, err)
+		})
+	}
+
+	c, err := parseBenchFlags([]string{"-slots", "5", "-mix", "read=3, incr=1:10-19"})
+	require.NoError(t, err)
+	assert.Equal(t, []benchMix{
+		{op: "read", weight: 3, slots: slotRange{from: 5, to: 5}},
+		{op: "incr", weight: 1, slots: slotRange{from: 10, to: 19}},
+	}, c.mix)
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	report := newLatencyReport(latencies)
+	assert.Equal(t, latencyReport{P50: 50, P90: 90, P99: 99, Max: 100}, report)
+	assert.Equal(t, latencyReport{}, newLatencyReport(nil))
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+)
+
+// command is a subcommand of the tool
+type command struct {
+	usage string
+	// check validates the arguments before connecting
+	check func(args []string) error
+	run   func(ctx context.Context, s *session, args []string) error
+	// longRunning commands keep reconnecting and are not bounded by the timeout
+	longRunning bool
+}
+
+// session is the connection used by a command and where it writes its results
+type session struct {
+	client *ghoti.Client
+	out    *output
+	stdin  io.Reader
+	// timeout bounds every request sent by the command
+	timeout time.Duration
+	// dial opens another client with the same configuration
+	dial func() (*ghoti.Client, error)
+}
+
+// commands lists the subcommands by name
+var commands = map[string]command{
+	"read": {
+		usage: "<slot>",
+		check: slotArgs(1, 1),
+		run:   read,
+	},
+	"write": {
+		usage: "<slot> <data>",
+		check: slotArgs(2, 2),
+		run:   write,
+	},
+	"broadcast": {
+		usage: "<slot> <data>",
+		check: slotArgs(2, 2),
+		run:   broadcast,
+	},
+	"watch": {
+		usage:       "<slot>...",
+		check:       watchArgs,
+		run:         watch,
+		longRunning: true,
+	},
+	"counter": {
+		usage: "get|incr|decr <slot> [delta]",
+		check: counterArgs,
+		run:   counter,
+	},
+	"bucket": {
+		usage: "take|acquire <slot>",
+		check: bucketArgs,
+		run:   bucket,
+	},
+	"bench": {
+		usage: "[-clients n] [-duration d] [-ops n] [-slots from-to] [-mix op=weight[:from-to],...] [-size n]",
+		check: func(args []string) error {
+			_, err := parseBenchFlags(args)
+			return err
+		},
+		run:         runBench,
+		longRunning: true,
+	},
+}
+
+// slotArgs checks the number of arguments and that the first one is a slot
+func slotArgs(minArgs, maxArgs int) func(args []string) error {
+	return func(args []string) error {
+		if len(args) < minArgs || len(args) > maxArgs {
+			return fmt.Errorf("wrong number of arguments")
+		}
+		_, err := parseSlot(args[0])
+		return err
+	}
+}
+
+// watchArgs checks every argument is a slot
+func watchArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("at least one slot is required")
+	}
+	for _, arg := range args {
+		if _, err := parseSlot(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// counterArgs checks the arguments of the counter command
+func counterArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing counter operation")
+	}
+
+	switch args[0] {
+	case "get":
+		return slotArgs(1, 1)(args[1:])
+	case "incr", "decr":
+		if err := slotArgs(1, 2)(args[1:]); err != nil {
+			return err
+		}
+		if len(args) == 3 {
+			if _, err := strconv.Atoi(args[2]); err != nil {
+				return fmt.Errorf("invalid delta %q: must be a number", args[2])
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown counter operation %q", args[0])
+	}
+}
+
+// bucketArgs checks the arguments of the bucket command
+func bucketArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing bucket operation")
+	}
+
+	switch args[0] {
+	case "take", "acquire":
+		return slotArgs(1, 1)(args[1:])
+	default:
+		return fmt.Errorf("unknown bucket operation %q", args[0])
+	}
+}
+
+// valueResult is the JSON output of commands returning the value of a slot
+type valueResult struct {
+	Slot  int    `json:"slot"`
+	Value string `json:"value"`
+}
+
+func read(ctx context.Context, s *session, args []string) error {
+	slot, _ := parseSlot(args[0])
+
+	value, err := s.client.ReadContext(ctx, slot)
+	if err != nil {
+		return err
+	}
+
+	s.out.result(value, valueResult{Slot: slot, Value: value})
+	return nil
+}
+
+func write(ctx context.Context, s *session, args []string) error {
+	slot, _ := parseSlot(args[0])
+
+	if err := s.client.WriteContext(ctx, slot, args[1]); err != nil {
+		return err
+	}
+
+	s.out.result("OK", valueResult{Slot: slot, Value: args[1]})
+	return nil
+}
+
+// broadcastResult is the JSON output of the broadcast command
+type broadcastResult struct {
+	Slot     int `json:"slot"`
+	Received int `json:"received"`
+	Total    int `json:"total"`
+	Failed   int `json:"failed"`
+}
+
+func broadcast(ctx context.Context, s *session, args []string) error {
+	slot, _ := parseSlot(args[0])
+
+	received, total, failed, err := s.client.BroadcastContext(ctx, slot, args[1])
+	if err != nil {
+		return err
+	}
+
+	s.out.result(
+		fmt.Sprintf("%d/%d clients received, %d failed", received, total, failed),
+		broadcastResult{Slot: slot, Received: received, Total: total, Failed: failed},
+	)
+	return nil
+}
+
+// messageResult is the JSON output of a broadcast received by the watch command
+type messageResult struct {
+	Slot int    `json:"slot"`
+	Data string `json:"data"`
+}
+
+func watch(ctx context.Context, s *session, args []string) error {
+	messages := make(chan ghoti.Message)
+
+	for _, arg := range args {
+		slot, _ := parseSlot(arg)
+
+		subscription, unsubscribe := s.client.Subscribe(slot, ghoti.WithOverflow(ghoti.Block))
+		defer unsubscribe()
+
+		go func() {
+			for message := range subscription {
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case message := <-messages:
+			s.out.result(fmt.Sprintf("%03d %s", message.Slot, message.Data), messageResult(message))
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// counterResult is the JSON output of the counter command
+type counterResult struct {
+	Slot  int `json:"slot"`
+	Value int `json:"value"`
+}
+
+func counter(ctx context.Context, s *session, args []string) error {
+	slot, _ := parseSlot(args[1])
+	counter := s.client.AtomicCounter(slot)
+
+	var value int
+	var err error
+	switch args[0] {
+	case "get":
+		value, err = counter.ReadContext(ctx)
+	default:
+		delta := 1
+		if len(args) == 3 {
+			delta, _ = strconv.Atoi(args[2])
+		}
+		if args[0] == "decr" {
+			delta = -delta
+		}
+		value, err = counter.AddContext(ctx, delta)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.out.result(strconv.Itoa(value), counterResult{Slot: slot, Value: value})
+	return nil
+}
+
+// bucketResult is the JSON output of the bucket command
+type bucketResult struct {
+	Slot      int  `json:"slot"`
+	Allowed   bool `json:"allowed"`
+	Remaining *int `json:"remaining,omitempty"`
+}
+
+func bucket(ctx context.Context, s *session, args []string) error {
+	slot, _ := parseSlot(args[1])
+
+	if args[0] == "acquire" {
+		allowed, err := s.client.LeakyBucket(slot).TryAcquireContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		text := "denied"
+		if allowed {
+			text = "allowed"
+		}
+		s.out.result(text, bucketResult{Slot: slot, Allowed: allowed})
+		return nil
+	}
+
+	remaining, err := s.client.TokenBucket(slot).GetTokensContext(ctx)
+	if errors.Is(err, ghoti.ErrNoTokens) {
+		s.out.result("denied", bucketResult{Slot: slot, Allowed: false})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.out.result(
+		fmt.Sprintf("allowed, %d tokens remaining", remaining),
+		bucketResult{Slot: slot, Allowed: true, Remaining: &remaining},
+	)
+	return nil
+}
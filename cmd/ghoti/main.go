@@ -0,0 +1,244 @@
+// Command ghoti is a command line client for Ghoti servers.
+//
+// Usage:
+//
+//	ghoti [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	read <slot>                 read the value of a slot
+//	write <slot> <data>         write a value to a slot
+//	broadcast <slot> <data>     send a message to the clients reading a slot
+//	watch <slot>...             print the broadcasts sent to the slots
+//	counter get <slot>          read an atomic counter
+//	counter incr <slot> [delta] add to an atomic counter, one by default
+//	counter decr <slot> [delta] subtract from an atomic counter, one by default
+//	bucket take <slot>          take a token from a token bucket
+//	bucket acquire <slot>       add a request to a leaky bucket
+//	shell [-history file]       run commands and protocol lines interactively
+//	bench [flags]               measure the throughput and latency of a server
+//
+// The connection is configured with the GHOTI_* environment variables, a
+// configuration file given with -config and the flags, in increasing order
+// of precedence. There is no flag for the password, so it doesn't show up in
+// the process list: set it with GHOTI_AUTH_PASS or in the configuration file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// globalFlags holds the flags shared by every command
+type globalFlags struct {
+	config   string
+	server   string
+	network  string
+	user     string
+	timeout  time.Duration
+	tls      bool
+	caFile   string
+	certFile string
+	keyFile  string
+	insecure bool
+	output   string
+}
+
+// parseFlags parses the global flags and returns the remaining arguments
+func parseFlags(args []string, stderr io.Writer) (*globalFlags, *flag.FlagSet, error) {
+	f := &globalFlags{}
+
+	flags := flag.NewFlagSet("ghoti", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&f.config, "config", "", "YAML or JSON configuration file")
+	flags.StringVar(&f.server, "server", "", "address of the server, such as localhost:9090")
+	flags.StringVar(&f.network, "network", "", "network used to reach the server: tcp, tcp4, tcp6 or unix")
+	flags.StringVar(&f.user, "user", "", "user to authenticate as, the password is read from GHOTI_AUTH_PASS or the configuration file")
+	flags.DurationVar(&f.timeout, "timeout", 0, "time to wait for each response")
+	flags.BoolVar(&f.tls, "tls", false, "connect with TLS")
+	flags.StringVar(&f.caFile, "ca-file", "", "PEM file with the certificates used to verify the server")
+	flags.StringVar(&f.certFile, "cert-file", "", "PEM client certificate for mutual TLS")
+	flags.StringVar(&f.keyFile, "key-file", "", "PEM client key for mutual TLS")
+	flags.BoolVar(&f.insecure, "insecure", false, "skip the verification of the server certificate")
+	flags.StringVar(&f.output, "o", "text", "output format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: ghoti [flags] <command> [arguments]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Commands: read, write, broadcast, watch, counter, bucket, shell, bench")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Flags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if f.output != "text" && f.output != "json" {
+		return nil, nil, fmt.Errorf("invalid output format %q: must be text or json", f.output)
+	}
+
+	return f, flags, nil
+}
+
+// options returns the client options set by the flags
+func (f *globalFlags) options() []ghoti.Option {
+	var opts []ghoti.Option
+
+	if f.network != "" {
+		opts = append(opts, ghoti.WithNetwork(f.network))
+	}
+	if f.user != "" {
+		user := f.user
+		opts = append(opts, func(c *ghoti.Config) error {
+			c.User = user
+			return nil
+		})
+	}
+	if f.timeout > 0 {
+		opts = append(opts, ghoti.WithTimeout(f.timeout))
+	}
+	if f.tls || f.caFile != "" || f.certFile != "" || f.keyFile != "" || f.insecure {
+		opts = append(opts, f.tlsOption())
+	}
+	if f.server != "" {
+		server := f.server
+		opts = append(opts, func(c *ghoti.Config) error {
+			c.Address = server
+			return nil
+		})
+	}
+
+	return opts
+}
+
+// tlsOption enables TLS, keeping the settings loaded from the environment and
+// the configuration file that the flags don't replace
+func (f *globalFlags) tlsOption() ghoti.Option {
+	return func(c *ghoti.Config) error {
+		config := ghoti.TLSConfig{}
+		if c.TLS != nil {
+			config = *c.TLS
+		}
+
+		if f.caFile != "" {
+			config.CAFile = f.caFile
+		}
+		if f.certFile != "" {
+			config.CertFile = f.certFile
+		}
+		if f.keyFile != "" {
+			config.KeyFile = f.keyFile
+		}
+		if f.insecure {
+			config.InsecureSkipVerify = true
+		}
+
+		return ghoti.WithTLS(config)(c)
+	}
+}
+
+// connect creates a client from the environment, the configuration file and
+// the flags, returning the timeout of its requests
+func (f *globalFlags) connect(reconnect bool) (*ghoti.Client, time.Duration, error) {
+	cfg, err := ghoti.LoadConfig(f.config, f.options()...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A single command fails right away instead of waiting for the server to return
+	if !reconnect {
+		cfg.Reconnect.Disabled = true
+	}
+
+	client, err := ghoti.NewClient(cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return client, cfg.Timeout(), nil
+}
+
+// run runs the command line and returns the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	f, flags, err := parseFlags(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	out := &output{json: f.output == "json", stdout: stdout, stderr: stderr}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	cmdArgs := flags.Args()[1:]
+	if err := cmd.check(cmdArgs); err != nil {
+		fmt.Fprintf(stderr, "%s\nUsage: ghoti %s %s\n", err, flags.Arg(0), cmd.usage)
+		return 2
+	}
+
+	client, timeout, err := f.connect(cmd.longRunning)
+	if err != nil {
+		out.error(err)
+		return 1
+	}
+	defer client.Close()
+
+	if !cmd.longRunning {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	s := &session{client: client, out: out, stdin: stdin, timeout: timeout}
+	s.dial = func() (*ghoti.Client, error) {
+		client, _, err := f.connect(cmd.longRunning)
+		return client, err
+	}
+	if err := cmd.run(ctx, s, cmdArgs); err != nil {
+		out.error(err)
+		return 1
+	}
+
+	return 0
+}
+
+// parseSlot parses a slot number argument
+func parseSlot(arg string) (int, error) {
+	slot, err := strconv.Atoi(arg)
+	if err != nil || slot < 0 || slot > protocol.MaxSlot {
+		return 0, fmt.Errorf("invalid slot %q: must be a number between 0 and %d", arg, protocol.MaxSlot)
+	}
+	return slot, nil
+}
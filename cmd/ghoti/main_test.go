@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts a server with a user and a slot of each type
+func newServer(t *testing.T) *ghotitest.Server {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("operator", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Value: "hello"}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.Broadcast}),
+		ghotitest.WithSlot(3, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter, Value: "10"}),
+		ghotitest.WithSlot(4, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(5, ghotitest.SlotConfig{Kind: ghotitest.LeakyBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(6, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Users: map[string]ghotitest.Permission{"operator": ghotitest.ReadWrite}}),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// runCommand runs the tool and returns its exit code and output
+func runCommand(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	server := newServer(t)
+	addr := server.Addr()
+
+	tests := map[string]struct {
+		args   []string
+		env    map[string]string
+		stdout string
+	}{
+		"read":           {args: []string{"read", "1"}, stdout: "hello\n"},
+		"read json":      {args: []string{"-o", "json", "read", "1"}, stdout: `{"slot":1,"value":"hello"}` + "\n"},
+		"write":          {args: []string{"write", "7", "value"}, stdout: "OK\n"},
+		"broadcast":      {args: []string{"-o", "json", "broadcast", "2", "hi"}, stdout: `{"slot":2,"received":0,"total":0,"failed":0}` + "\n"},
+		"counter get":    {args: []string{"counter", "get", "3"}, stdout: "10\n"},
+		"counter incr":   {args: []string{"counter", "incr", "3", "5"}, stdout: "15\n"},
+		"counter decr":   {args: []string{"counter", "decr", "3"}, stdout: "14\n"},
+		"bucket take":    {args: []string{"-o", "json", "bucket", "take", "4"}, stdout: `{"slot":4,"allowed":true,"remaining":0}` + "\n"},
+		"bucket empty":   {args: []string{"bucket", "take", "4"}, stdout: "denied\n"},
+		"bucket acquire": {args: []string{"bucket", "acquire", "5"}, stdout: "allowed\n"},
+		"auth":           {args: []string{"-user", "operator", "read", "6"}, env: map[string]string{"GHOTI_AUTH_PASS": "secret"}, stdout: "\n"},
+	}
+
+	// The tests depend on each other, so they run in this order
+	order := []string{"read", "read json", "write", "broadcast", "counter get", "counter incr", "counter decr", "bucket take", "bucket empty", "bucket acquire", "auth"}
+
+	for _, name := range order {
+		test := tests[name]
+		t.Run(name, func(t *testing.T) {
+			// Wait for the server to drop the previous connections, so the
+			// broadcast has nobody to send to
+			require.Eventually(t, func() bool { return server.Connections() == 0 }, time.Second, time.Millisecond)
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			code, stdout, stderr := runCommand(t, append([]string{"-server", addr}, test.args...)...)
+			assert.Equal(t, 0, code, stderr)
+			assert.Equal(t, test.stdout, stdout)
+		})
+	}
+
+	assert.Equal(t, "value", server.Value(7))
+}
+
+func TestCommandErrors(t *testing.T) {
+	server := newServer(t)
+
+	code, _, stderr := runCommand(t, "-server", server.Addr(), "-o", "json", "read", "6")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, `"code":"004"`)
+
+	code, _, stderr = runCommand(t, "-server", server.Addr(), "read", "1000")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "invalid slot")
+
+	code, _, stderr = runCommand(t, "-server", server.Addr(), "counter", "reset", "3")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "unknown counter operation")
+
+	code, _, _ = runCommand(t, "-server", server.Addr(), "unknown")
+	assert.Equal(t, 2, code)
+
+	code, _, stderr = runCommand(t, "read", "1")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "address is required")
+}
+
+func TestTLSFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ghoti.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server: ghoti:9090\ntls:\n  server_name: ghoti.internal\n"), 0o600))
+
+	// The flags are merged into the TLS settings of the configuration file
+	f, _, err := parseFlags([]string{"-config", path, "-tls", "-insecure", "read", "1"}, io.Discard)
+	require.NoError(t, err)
+
+	cfg, err := ghoti.LoadConfig(f.config, f.options()...)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.TLS)
+	assert.Equal(t, "ghoti.internal", cfg.TLS.ServerName)
+	assert.True(t, cfg.TLS.InsecureSkipVerify)
+}
+
+func TestEnvironment(t *testing.T) {
+	server := newServer(t)
+
+	t.Setenv("GHOTI_SERVER", server.Addr())
+	t.Setenv("GHOTI_AUTH_USER", "operator")
+	t.Setenv("GHOTI_AUTH_PASS", "secret")
+
+	code, _, stderr := runCommand(t, "write", "6", "from-env")
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "from-env", server.Value(6))
+}
+
+// syncBuffer is a buffer safe to write from the command while the test reads it
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func TestWatch(t *testing.T) {
+	server := newServer(t)
+	server.SetSlot(8, ghotitest.SlotConfig{Kind: ghotitest.Broadcast})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stdout, stderr syncBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- run(ctx, []string{"-server", server.Addr(), "watch", "2", "8"}, strings.NewReader(""), &stdout, &stderr)
+	}()
+
+	sender, err := ghoti.New(server.Addr())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	// Broadcast until the watcher is connected and got both messages
+	require.Eventually(t, func() bool {
+		sender.Broadcast(2, "first")
+		sender.Broadcast(8, "second")
+		text := stdout.String()
+		return strings.Contains(text, "002 first\n") && strings.Contains(text, "008 second\n")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Equal(t, 0, <-done, stderr.String())
+}
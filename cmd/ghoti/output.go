@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+// output writes the results of the commands as text or JSON
+type output struct {
+	json   bool
+	stdout io.Writer
+	stderr io.Writer
+	mutex  sync.Mutex
+}
+
+// result writes a result, as the text or as the JSON encoding of value
+func (o *output) result(text string, value interface{}) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if !o.json {
+		fmt.Fprintln(o.stdout, text)
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	fmt.Fprintln(o.stdout, string(data))
+}
+
+// write writes text as is to the standard output
+func (o *output) write(text string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	fmt.Fprint(o.stdout, text)
+}
+
+// errorResult is the JSON output of a failed command
+type errorResult struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// error writes an error to the standard error, including the code of errors
+// sent by the server
+func (o *output) error(err error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if !o.json {
+		fmt.Fprintln(o.stderr, "error:", err)
+		return
+	}
+
+	result := errorResult{Error: err.Error()}
+	var ghotiErr *model.GhotiError
+	if errors.As(err, &ghotiErr) {
+		result.Code = ghotiErr.Code
+	}
+
+	data, _ := json.Marshal(result)
+	fmt.Fprintln(o.stderr, string(data))
+}
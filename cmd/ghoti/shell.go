@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// shellPrompt is written before every line read by the shell
+const shellPrompt = "ghoti> "
+
+// historyFile is the default file where the shell keeps the history, in the
+// home directory of the user
+const historyFile = ".ghoti_history"
+
+// shellAliases maps the shortcuts of the shell to the commands they run
+var shellAliases = map[string][]string{
+	"get":     {"counter", "get"},
+	"incr":    {"counter", "incr"},
+	"decr":    {"counter", "decr"},
+	"take":    {"bucket", "take"},
+	"acquire": {"bucket", "acquire"},
+}
+
+// The shell runs the other commands, so it is registered after them
+func init() {
+	commands["shell"] = command{
+		usage: "[-history file]",
+		check: func(args []string) error {
+			_, err := parseShellFlags(args)
+			return err
+		},
+		run:         runShell,
+		longRunning: true,
+	}
+}
+
+// parseShellFlags parses the flags of the shell command and returns the path
+// of the history file, empty if the history is not saved
+func parseShellFlags(args []string) (string, error) {
+	var history string
+	if home, err := os.UserHomeDir(); err == nil {
+		history = filepath.Join(home, historyFile)
+	}
+
+	flags := flag.NewFlagSet("shell", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&history, "history", history, "file where the history is kept, empty to not save it")
+
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if flags.NArg() > 0 {
+		return "", fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	return history, nil
+}
+
+// shell is an interactive session that runs friendly commands and raw
+// protocol lines, showing the broadcasts as they arrive
+type shell struct {
+	*session
+	history []string
+	file    *os.File
+
+	// reading tells if the shell waits for a line, so broadcasts shown
+	// meanwhile write the prompt again
+	mutex   sync.Mutex
+	reading bool
+}
+
+// runShell runs the shell until the input ends, the user exits or the
+// context is done
+func runShell(ctx context.Context, s *session, args []string) error {
+	path, _ := parseShellFlags(args)
+
+	sh := &shell{session: s}
+	if path != "" {
+		if err := sh.openHistory(path); err != nil {
+			return err
+		}
+		defer sh.file.Close()
+	}
+
+	s.client.SetBroadcastHandler(sh.broadcast)
+	defer s.client.SetBroadcastHandler(nil)
+
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(s.stdin)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		sh.setReading(true)
+		sh.prompt()
+
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-lines:
+		case <-ctx.Done():
+			return nil
+		}
+		sh.setReading(false)
+
+		if !ok {
+			return nil
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if line == "exit" || line == "quit" {
+			return nil
+		}
+
+		line, err := sh.expand(line)
+		if err != nil {
+			s.out.error(err)
+			continue
+		}
+
+		sh.record(line)
+		sh.execute(ctx, line)
+	}
+}
+
+// openHistory loads the history saved in path and opens it to add the new lines
+func (sh *shell) openHistory(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sh.history = append(sh.history, line)
+		}
+	}
+
+	sh.file, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+
+	return nil
+}
+
+// record adds a line to the history. Password commands are left out so the
+// password is neither kept in memory nor written to the history file.
+func (sh *shell) record(line string) {
+	if line[0] == byte(protocol.OpPass) {
+		return
+	}
+
+	sh.history = append(sh.history, line)
+	if sh.file != nil {
+		fmt.Fprintln(sh.file, line)
+	}
+}
+
+// expand replaces !! with the last line of the history and !n with its line n
+func (sh *shell) expand(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+
+	if len(sh.history) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+
+	if line == "!!" {
+		return sh.history[len(sh.history)-1], nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(sh.history) {
+		return "", fmt.Errorf("%s: not in the history", line)
+	}
+
+	return sh.history[n-1], nil
+}
+
+// rawResult is the JSON output of a protocol line sent by the shell
+type rawResult struct {
+	Line     string `json:"line"`
+	Response string `json:"response"`
+}
+
+// execute runs a line, a shell command or a raw protocol line
+func (sh *shell) execute(ctx context.Context, line string) {
+	args := strings.Fields(line)
+
+	switch args[0] {
+	case "help":
+		sh.out.write(shellHelp)
+		return
+	case "history":
+		var text strings.Builder
+		for i, entry := range sh.history {
+			fmt.Fprintf(&text, "%5d  %s\n", i+1, entry)
+		}
+		sh.out.write(text.String())
+		return
+	}
+
+	name := args[0]
+	if alias, ok := shellAliases[name]; ok {
+		args = append(append([]string{}, alias...), args[1:]...)
+		name = args[0]
+	}
+
+	// Data can contain spaces, everything after the slot is sent
+	if name == "write" || name == "broadcast" {
+		args = splitArgs(line, 3)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sh.timeout)
+	defer cancel()
+
+	cmd, ok := commands[name]
+	if !ok || cmd.longRunning {
+		response, err := sh.client.RawContext(ctx, line)
+		if err != nil {
+			sh.out.error(err)
+			return
+		}
+		sh.out.result(response, rawResult{Line: line, Response: response})
+		return
+	}
+
+	if err := cmd.check(args[1:]); err != nil {
+		sh.out.error(fmt.Errorf("%w, usage: %s %s", err, name, cmd.usage))
+		return
+	}
+
+	if err := cmd.run(ctx, sh.session, args[1:]); err != nil {
+		sh.out.error(err)
+	}
+}
+
+// shellHelp lists the commands of the shell
+const shellHelp = `Commands:
+  read <slot>                 read the value of a slot
+  write <slot> <data>         write a value to a slot
+  broadcast <slot> <data>     send a message to the clients reading a slot
+  get <slot>                  read an atomic counter
+  incr <slot> [delta]         add to an atomic counter
+  decr <slot> [delta]         subtract from an atomic counter
+  take <slot>                 take a token from a token bucket
+  acquire <slot>              add a request to a leaky bucket
+  history                     list the history
+  !! or !<n>                  run the last line or line n of the history
+  exit                        leave the shell
+Any other line is sent as is, such as r005 or w005data.
+`
+
+// prompt writes the prompt, unless the output is JSON
+func (sh *shell) prompt() {
+	if !sh.out.json {
+		sh.out.write(shellPrompt)
+	}
+}
+
+// setReading records whether the shell waits for a line
+func (sh *shell) setReading(reading bool) {
+	sh.mutex.Lock()
+	sh.reading = reading
+	sh.mutex.Unlock()
+}
+
+// broadcast shows a broadcast received while the shell runs
+func (sh *shell) broadcast(slot int, data string) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	if sh.out.json {
+		sh.out.result("", messageResult{Slot: slot, Data: data})
+		return
+	}
+
+	text := fmt.Sprintf("broadcast %03d %s", slot, data)
+	if sh.reading {
+		// Start over the line holding the prompt and write it again below
+		sh.out.write("\r" + text + "\n" + shellPrompt)
+		return
+	}
+	sh.out.result(text, nil)
+}
+
+// splitArgs splits a line in at most n fields separated by spaces, the last
+// field holds the rest of the line
+func splitArgs(line string, n int) []string {
+	var args []string
+	line = strings.TrimSpace(line)
+	for len(args) < n-1 {
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			break
+		}
+		args = append(args, line[:i])
+		line = strings.TrimLeft(line[i:], " \t")
+	}
+	return append(args, line)
+}
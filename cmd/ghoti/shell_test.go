@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShell(t *testing.T) {
+	server := newServer(t)
+	history := filepath.Join(t.TempDir(), "history")
+	require.NoError(t, os.WriteFile(history, []byte("read 1\n"), 0o600))
+
+	input := strings.Join([]string{
+		"read 1",
+		"write 7 hello world",
+		"incr 3 5",
+		"r007",
+		"r006",
+		"read 1000",
+		"!1",
+		"history",
+		"exit",
+		"read 1",
+	}, "\n")
+
+	var stdout, stderr syncBuffer
+	code := run(context.Background(), []string{"-server", server.Addr(), "shell", "-history", history}, strings.NewReader(input), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	output := stdout.String()
+	assert.True(t, strings.HasPrefix(output, shellPrompt+"hello\n"), output)
+	assert.Contains(t, output, shellPrompt+"OK\n")
+	assert.Contains(t, output, shellPrompt+"15\n")
+	assert.Contains(t, output, shellPrompt+"v007hello world\n")
+	assert.Equal(t, 2, strings.Count(output, "hello\n"), "!1 runs the first line of the saved history")
+	assert.Contains(t, output, "    1  read 1\n    2  read 1\n    3  write 7 hello world\n")
+
+	assert.Contains(t, stderr.String(), "error: Ghoti error 004: Authentication required\n")
+	assert.Contains(t, stderr.String(), "usage: read <slot>\n")
+
+	// The lines are added to the history file, up to the exit
+	saved, err := os.ReadFile(history)
+	require.NoError(t, err)
+	assert.Equal(t, "read 1\nread 1\nwrite 7 hello world\nincr 3 5\nr007\nr006\nread 1000\nread 1\nhistory\n", string(saved))
+}
+
+func TestShellPasswordHistory(t *testing.T) {
+	server := newServer(t)
+	history := filepath.Join(t.TempDir(), "history")
+
+	input := strings.Join([]string{
+		"uoperator",
+		"psecret",
+		"r006",
+		"history",
+	}, "\n")
+
+	var stdout, stderr syncBuffer
+	code := run(context.Background(), []string{"-server", server.Addr(), "shell", "-history", history}, strings.NewReader(input), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+
+	// The password is sent but not kept in the history
+	output := stdout.String()
+	assert.Contains(t, output, shellPrompt+"voperator\n")
+	assert.Contains(t, output, shellPrompt+"v006\n")
+	assert.Contains(t, output, "    1  uoperator\n    2  r006\n    3  history\n")
+	assert.NotContains(t, output, "secret")
+
+	saved, err := os.ReadFile(history)
+	require.NoError(t, err)
+	assert.Equal(t, "uoperator\nr006\nhistory\n", string(saved))
+}
+
+func TestShellBroadcasts(t *testing.T) {
+	server := newServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The input stays open until the test ends
+	stdin, input := io.Pipe()
+	defer input.Close()
+
+	var stdout, stderr syncBuffer
+	done := make(chan int, 1)
+	go func() {
+		done <- run(ctx, []string{"-server", server.Addr(), "-o", "json", "shell", "-history", ""}, stdin, &stdout, &stderr)
+	}()
+
+	_, err := io.WriteString(input, "r001\n")
+	require.NoError(t, err)
+
+	sender, err := ghoti.New(server.Addr())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	require.Eventually(t, func() bool {
+		sender.Broadcast(2, "inline")
+		text := stdout.String()
+		return strings.Contains(text, `{"line":"r001","response":"v001hello"}`) &&
+			strings.Contains(text, `{"slot":2,"data":"inline"}`)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.Equal(t, 0, <-done, stderr.String())
+}
+
+func TestSplitArgs(t *testing.T) {
+	assert.Equal(t, []string{"write", "5", "hello  world"}, splitArgs("  write  5 hello  world ", 3))
+	assert.Equal(t, []string{"write", "5"}, splitArgs("write 5", 3))
+	assert.Equal(t, []string{"write"}, splitArgs("write", 3))
+}
@@ -7,48 +7,29 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
 	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
 )
 
 func main() {
-	// Load default configuration
-	cfg := config.LoadDefaultConfig()
-
-	// Create a new client
-	client, err := ghoti.NewClient(cfg)
+	// Create a new client, it authenticates as soon as it connects
+	client, err := ghoti.New("localhost:9090",
+		ghoti.WithAuth("test_a_service", "67890"),
+		ghoti.WithTimeout(5*time.Second),
+	)
 	if err != nil {
 		fmt.Printf("Failed to create client: %v\n", err)
 		return
 	}
 	defer client.Close()
+	fmt.Println("Authentication successful")
 
 	// Set up a broadcast handler
 	client.SetBroadcastHandler(func(slot int, data string) {
 		fmt.Printf("Received broadcast on slot %d: %s\n", slot, data)
 	})
 
-	// Authenticate
-	err = client.Auth()
-	if err != nil {
-		fmt.Printf("Failed to authenticate: %v\n", err)
-		return
-	}
-	fmt.Println("Authentication successful")
-
 	// Get a simple memory slot
-	simpleSlot, err := client.GetSlot(ghoti.SimpleMemory, 0)
-	if err != nil {
-		fmt.Printf("Failed to get slot: %v\n", err)
-		return
-	}
-	
-	// Type assertion
-	memorySlot, ok := simpleSlot.(*ghoti.SimpleMemorySlot)
-	if !ok {
-		fmt.Println("Failed to cast to SimpleMemorySlot")
-		return
-	}
+	memorySlot := client.SimpleMemory(0)
 
 	// Write to the slot
 	err = memorySlot.Write("Hello, Ghoti!")
@@ -67,18 +48,7 @@ func main() {
 	fmt.Printf("Read value: %s\n", value)
 
 	// Get a broadcast slot
-	broadcastSlot, err := client.GetSlot(ghoti.Broadcast, 1)
-	if err != nil {
-		fmt.Printf("Failed to get broadcast slot: %v\n", err)
-		return
-	}
-	
-	// Type assertion
-	bcastSlot, ok := broadcastSlot.(*ghoti.BroadcastSlot)
-	if !ok {
-		fmt.Println("Failed to cast to BroadcastSlot")
-		return
-	}
+	bcastSlot := client.BroadcastSlot(1)
 
 	// Send a broadcast message
 	received, total, failed, err := bcastSlot.Send("Broadcast message")
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"log/slog"
+	"time"
+)
+
 type Config interface {
 	Protocol() string
 	Server() string
@@ -12,3 +17,21 @@ type AuthConfig interface {
 	User() string
 	Pass() string
 }
+
+// AutoAuthConfig can be implemented by a Config to have the client
+// authenticate as soon as it connects
+type AutoAuthConfig interface {
+	AutoAuth() bool
+}
+
+// TimeoutConfig can be implemented by a Config to change how long the client
+// waits for a response when the caller gives no context
+type TimeoutConfig interface {
+	Timeout() time.Duration
+}
+
+// LoggerConfig can be implemented by a Config to have the client write its
+// logs with the given logger instead of discarding them
+type LoggerConfig interface {
+	Logger() *slog.Logger
+}
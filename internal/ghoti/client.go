@@ -1,14 +1,18 @@
 package ghoti
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/internal/logging"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
 )
 
 // BroadcastHandler is a function that handles broadcast messages
@@ -18,10 +22,11 @@ type BroadcastHandler func(slot int, data string)
 type GhotiClient struct {
 	config           config.Config
 	conn             net.Conn
-	reader           *bufio.Reader
+	decoder          *protocol.Decoder
 	mutex            sync.Mutex
 	pendingRequests  map[int]chan string
 	broadcastHandler BroadcastHandler
+	logger           *slog.Logger
 	done             chan struct{}
 	wg               sync.WaitGroup
 }
@@ -36,8 +41,9 @@ func NewFromConfig(config config.Config) (*GhotiClient, error) {
 	client := &GhotiClient{
 		config:          config,
 		conn:            conn,
-		reader:          bufio.NewReader(conn),
+		decoder:         protocol.NewDecoder(conn),
 		pendingRequests: make(map[int]chan string),
+		logger:          loggerFor(config),
 		done:            make(chan struct{}),
 	}
 
@@ -48,6 +54,15 @@ func NewFromConfig(config config.Config) (*GhotiClient, error) {
 	return client, nil
 }
 
+// loggerFor returns the logger set by the configuration, or one that discards
+// everything if it doesn't set one
+func loggerFor(c config.Config) *slog.Logger {
+	if loggerConfig, ok := c.(config.LoggerConfig); ok && loggerConfig.Logger() != nil {
+		return loggerConfig.Logger()
+	}
+	return logging.Discard()
+}
+
 // SetBroadcastHandler sets the handler for broadcast messages
 func (c *GhotiClient) SetBroadcastHandler(handler BroadcastHandler) {
 	c.mutex.Lock()
@@ -58,8 +73,10 @@ func (c *GhotiClient) SetBroadcastHandler(handler BroadcastHandler) {
 // Close closes the connection to the server
 func (c *GhotiClient) Close() error {
 	close(c.done)
+	// Closing the connection unblocks the listener
+	err := c.conn.Close()
 	c.wg.Wait()
-	return c.conn.Close()
+	return err
 }
 
 // listen continuously reads messages from the server and processes them
@@ -71,112 +88,67 @@ func (c *GhotiClient) listen() {
 		case <-c.done:
 			return
 		default:
-			line, err := c.reader.ReadString('\n')
+			message, err := c.decoder.DecodeMessage()
+			if errors.Is(err, model.ErrInvalidResponse) {
+				c.handleError(err)
+				continue
+			}
 			if err != nil {
 				// Connection closed or error
 				c.handleError(fmt.Errorf("connection error: %w", err))
 				return
 			}
 
-			line = strings.TrimSuffix(line, "\n")
-			if len(line) == 0 {
-				continue
-			}
-
 			// Process the message
-			c.processMessage(line)
+			c.processMessage(message)
 		}
 	}
 }
 
 // processMessage processes a message received from the server
-func (c *GhotiClient) processMessage(message string) {
-	if len(message) == 0 {
-		return
-	}
-
-	messageType := message[0]
-	switch messageType {
-	case 'v': // Value response
+func (c *GhotiClient) processMessage(message protocol.Message) {
+	switch message.Kind {
+	case protocol.KindValue:
 		c.handleValueResponse(message)
-	case 'e': // Error response
-		c.handleErrorResponse(message)
-	case 'a': // Async/broadcast message
-		c.handleBroadcastMessage(message)
-	default:
-		c.handleError(fmt.Errorf("unknown message type: %c", messageType))
+	case protocol.KindError:
+		// For now, we'll just log the error
+		// In a real implementation, you might want to forward this to the appropriate request
+		c.logger.Warn("server error", slog.String("code", message.Code))
+	case protocol.KindBroadcast:
+		c.handleBroadcastMessage(message.Slot, message.Data)
 	}
 }
 
 // handleValueResponse processes a value response from the server
-func (c *GhotiClient) handleValueResponse(message string) {
-	// Value responses for slot operations have format: v000data
-	if len(message) < 4 {
-		c.handleError(fmt.Errorf("invalid value response format: %s", message))
-		return
-	}
-
-	// Extract slot number
-	slotStr := message[1:4]
-	slot, err := strconv.Atoi(slotStr)
-	if err != nil {
-		c.handleError(fmt.Errorf("invalid slot number in response: %s", slotStr))
-		return
-	}
-
-	// Extract data
-	data := message[4:]
-
-	// Forward to waiting request if any
-	c.mutex.Lock()
-	ch, exists := c.pendingRequests[slot]
-	c.mutex.Unlock()
-
-	if exists {
-		ch <- data
-	} else {
-		// This could be a response to an auth command
-		if strings.HasPrefix(data, c.config.Auth().User()) {
-			// This is likely an auth response, ignore it
-			return
+func (c *GhotiClient) handleValueResponse(message protocol.Message) {
+	// Value responses for slot operations have format: v000data, auth
+	// responses have format: v<user>
+	if len(message.Data) >= 3 {
+		if slot, err := strconv.Atoi(message.Data[:3]); err == nil {
+			// Forward to waiting request if any
+			c.mutex.Lock()
+			ch, exists := c.pendingRequests[slot]
+			c.mutex.Unlock()
+
+			if exists {
+				ch <- message.Data[3:]
+				return
+			}
 		}
-		// Unexpected response
-		c.handleError(fmt.Errorf("received response for slot %d with no pending request", slot))
 	}
-}
 
-// handleErrorResponse processes an error response from the server
-func (c *GhotiClient) handleErrorResponse(message string) {
-	// Error responses have format: e000
-	if len(message) < 4 {
-		c.handleError(fmt.Errorf("invalid error response format: %s", message))
+	// This could be a response to an auth command
+	if strings.HasPrefix(message.Data, c.config.Auth().User()) {
+		// This is likely an auth response, ignore it
 		return
 	}
 
-	// For now, we'll just log the error
-	// In a real implementation, you might want to forward this to the appropriate request
-	fmt.Printf("Server error: %s\n", message[1:])
+	// Unexpected response
+	c.handleError(fmt.Errorf("received response with no pending request: %s", message))
 }
 
 // handleBroadcastMessage processes a broadcast message from the server
-func (c *GhotiClient) handleBroadcastMessage(message string) {
-	// Broadcast messages have format: a000data
-	if len(message) < 4 {
-		c.handleError(fmt.Errorf("invalid broadcast message format: %s", message))
-		return
-	}
-
-	// Extract slot number
-	slotStr := message[1:4]
-	slot, err := strconv.Atoi(slotStr)
-	if err != nil {
-		c.handleError(fmt.Errorf("invalid slot number in broadcast: %s", slotStr))
-		return
-	}
-
-	// Extract data
-	data := message[4:]
-
+func (c *GhotiClient) handleBroadcastMessage(slot int, data string) {
 	// Call the broadcast handler if set
 	c.mutex.Lock()
 	handler := c.broadcastHandler
@@ -190,16 +162,14 @@ func (c *GhotiClient) handleBroadcastMessage(message string) {
 // handleError handles an error in the client
 func (c *GhotiClient) handleError(err error) {
 	// For critical errors, close the connection
-	fmt.Printf("Client error: %v\n", err)
+	c.logger.Error("client error", slog.String("error", err.Error()))
 	// In a real implementation, you might want to reconnect or notify the user
 }
 
 // Auth authenticates with the server using the configured credentials
 func (c *GhotiClient) Auth() error {
 	// Send user command
-	userCmd := fmt.Sprintf("u%s\n", c.config.Auth().User())
-	_, err := c.conn.Write([]byte(userCmd))
-	if err != nil {
+	if err := c.send(protocol.User(c.config.Auth().User())); err != nil {
 		return fmt.Errorf("failed to send user command: %w", err)
 	}
 
@@ -207,9 +177,7 @@ func (c *GhotiClient) Auth() error {
 	// In a real implementation, you might want to wait for a specific response
 
 	// Send password command
-	passCmd := fmt.Sprintf("p%s\n", c.config.Auth().Pass())
-	_, err = c.conn.Write([]byte(passCmd))
-	if err != nil {
+	if err := c.send(protocol.Pass(c.config.Auth().Pass())); err != nil {
 		return fmt.Errorf("failed to send password command: %w", err)
 	}
 
@@ -219,10 +187,22 @@ func (c *GhotiClient) Auth() error {
 	return nil
 }
 
+// send validates a command and writes it to the connection
+func (c *GhotiClient) send(cmd protocol.Command) error {
+	line, err := protocol.AppendCommand(nil, cmd)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.conn.Write(line)
+	return err
+}
+
 // Read reads the value from a slot
 func (c *GhotiClient) Read(slot int) (string, error) {
-	if slot < 0 || slot > 999 {
-		return "", fmt.Errorf("invalid slot number: %d", slot)
+	cmd := protocol.Read(slot)
+	if err := cmd.Validate(); err != nil {
+		return "", err
 	}
 
 	// Create a channel to receive the response
@@ -241,9 +221,7 @@ func (c *GhotiClient) Read(slot int) (string, error) {
 	}()
 
 	// Send the read command
-	cmd := fmt.Sprintf("r%03d\n", slot)
-	_, err := c.conn.Write([]byte(cmd))
-	if err != nil {
+	if err := c.send(cmd); err != nil {
 		return "", fmt.Errorf("failed to send read command: %w", err)
 	}
 
@@ -258,12 +236,9 @@ func (c *GhotiClient) Read(slot int) (string, error) {
 
 // Write writes a value to a slot
 func (c *GhotiClient) Write(slot int, data string) error {
-	if slot < 0 || slot > 999 {
-		return fmt.Errorf("invalid slot number: %d", slot)
-	}
-
-	if len(data) > 36 {
-		return fmt.Errorf("data too long: maximum length is 36 characters")
+	cmd := protocol.Write(slot, data)
+	if err := cmd.Validate(); err != nil {
+		return err
 	}
 
 	// Create a channel to receive the response
@@ -282,9 +257,7 @@ func (c *GhotiClient) Write(slot int, data string) error {
 	}()
 
 	// Send the write command
-	cmd := fmt.Sprintf("w%03d%s\n", slot, data)
-	_, err := c.conn.Write([]byte(cmd))
-	if err != nil {
+	if err := c.send(cmd); err != nil {
 		return fmt.Errorf("failed to send write command: %w", err)
 	}
 
@@ -299,12 +272,9 @@ func (c *GhotiClient) Write(slot int, data string) error {
 
 // Broadcast sends a message to all connected clients
 func (c *GhotiClient) Broadcast(slot int, data string) (int, int, int, error) {
-	if slot < 0 || slot > 999 {
-		return 0, 0, 0, fmt.Errorf("invalid slot number: %d", slot)
-	}
-
-	if len(data) > 36 {
-		return 0, 0, 0, fmt.Errorf("data too long: maximum length is 36 characters")
+	cmd := protocol.Write(slot, data)
+	if err := cmd.Validate(); err != nil {
+		return 0, 0, 0, err
 	}
 
 	// Create a channel to receive the response
@@ -323,9 +293,7 @@ func (c *GhotiClient) Broadcast(slot int, data string) (int, int, int, error) {
 	}()
 
 	// Send the write command (broadcast uses the write command)
-	cmd := fmt.Sprintf("w%03d%s\n", slot, data)
-	_, err := c.conn.Write([]byte(cmd))
-	if err != nil {
+	if err := c.send(cmd); err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to send broadcast command: %w", err)
 	}
 
@@ -4,15 +4,37 @@ import (
 	"testing"
 
 	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
 	"github.com/stretchr/testify/assert"
 )
 
+// testConfig points the client to a test server
+type testConfig struct {
+	server string
+}
+
+func (c *testConfig) Protocol() string        { return "tcp" }
+func (c *testConfig) Server() string          { return c.server }
+func (c *testConfig) ReadBufferSize() int     { return 8 * 1024 }
+func (c *testConfig) Auth() config.AuthConfig { return c }
+func (c *testConfig) User() string            { return "test_a_service" }
+func (c *testConfig) Pass() string            { return "67890" }
+
 func TestClient(t *testing.T) {
-	cfg := config.LoadDefaultConfig()
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("test_a_service", "67890"),
+		ghotitest.WithSlot(4, ghotitest.SlotConfig{
+			Kind:  ghotitest.SimpleMemory,
+			Users: map[string]ghotitest.Permission{"test_a_service": ghotitest.ReadWrite},
+		}),
+	)
+	defer server.Close()
+
+	cfg := &testConfig{server: server.Addr()}
 
 	client, err := NewFromConfig(cfg)
 	if err != nil {
-		t.Errorf("Failed to create client: %v", err)
+		t.Fatalf("Failed to create client: %v", err)
 	}
 	defer client.Close()
 
@@ -28,7 +50,7 @@ func TestClient(t *testing.T) {
 
 	assert.Equal(t, "This is a test", value)
 
-	client.Auth()
+	err = client.Auth()
 	if err != nil {
 		t.Errorf("Failed to authenticate client: %v", err)
 	}
@@ -0,0 +1,75 @@
+// Package logging holds the helpers shared by the clients to write structured
+// logs with log/slog
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+// Redacted replaces secrets in the logs
+const Redacted = "[REDACTED]"
+
+// discardHandler is a slog.Handler that drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// Discard returns a logger that drops everything, used when no logger is configured
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// Command returns the attributes describing a command line, without its data
+// so values and credentials never reach the logs
+func Command(cmd string) []slog.Attr {
+	if len(cmd) == 0 {
+		return nil
+	}
+
+	switch cmd[0] {
+	case 'r', 'w':
+		name := "read"
+		if cmd[0] == 'w' {
+			name = "write"
+		}
+
+		attrs := []slog.Attr{slog.String("command", name)}
+		if len(cmd) >= 4 {
+			if slot, err := strconv.Atoi(cmd[1:4]); err == nil {
+				attrs = append(attrs, slog.Int("slot", slot))
+			}
+		}
+		return attrs
+	case 'u':
+		return []slog.Attr{slog.String("command", "user")}
+	case 'p':
+		return []slog.Attr{slog.String("command", "password")}
+	default:
+		return []slog.Attr{slog.String("command", "unknown")}
+	}
+}
+
+// Error returns the attributes describing an error, including the code of
+// errors sent by the server
+func Error(err error) []slog.Attr {
+	if err == nil {
+		return nil
+	}
+
+	attrs := []slog.Attr{slog.String("error", err.Error())}
+
+	var ghotiErr *model.GhotiError
+	if errors.As(err, &ghotiErr) {
+		attrs = append(attrs, slog.String("code", ghotiErr.Code))
+	}
+
+	return attrs
+}
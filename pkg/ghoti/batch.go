@@ -0,0 +1,238 @@
+package ghoti
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// Result is the outcome of an operation of a batch
+type Result struct {
+	// Slot is the slot of the operation
+	Slot int
+	// Value is the data of the response, the value of the slot for reads and
+	// what the server answered for writes
+	Value string
+	// Err is the error of the operation, the other operations of the batch
+	// are not affected by it
+	Err error
+}
+
+// batcher sends the commands of a batch, it is implemented by Client and Pool
+type batcher interface {
+	sendMany(ctx context.Context, cmds []protocol.Command) ([]Response, error)
+	timeoutContext() (context.Context, context.CancelFunc)
+}
+
+// batchOp is an operation added to a batch
+type batchOp struct {
+	cmd protocol.Command
+	// err is set if the operation is not valid, it is not sent
+	err error
+}
+
+// Batch collects operations and sends them to the server in a single write,
+// collecting the responses in order. It takes one round trip instead of one
+// per operation.
+type Batch struct {
+	client batcher
+	ops    []batchOp
+}
+
+// Batch returns an empty batch sending its operations through the client
+func (c *Client) Batch() *Batch {
+	return &Batch{client: c}
+}
+
+// Read adds a read of a slot to the batch
+func (b *Batch) Read(slot int) *Batch {
+	cmd := protocol.Read(slot)
+	b.ops = append(b.ops, batchOp{cmd: cmd, err: cmd.Validate()})
+	return b
+}
+
+// Write adds a write of a value to a slot to the batch
+func (b *Batch) Write(slot int, data string) *Batch {
+	cmd := protocol.Write(slot, data)
+	b.ops = append(b.ops, batchOp{cmd: cmd, err: cmd.Validate()})
+	return b
+}
+
+// Len returns the number of operations in the batch
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+// Run sends the batch and returns the results in the order the operations
+// were added
+func (b *Batch) Run() ([]Result, error) {
+	ctx, cancel := b.client.timeoutContext()
+	defer cancel()
+	return b.RunContext(ctx)
+}
+
+// RunContext sends the batch and returns the results in the order the
+// operations were added, waiting for the responses until the context is
+// done. The error is only set if the batch could not be sent, the errors of
+// the operations, including the ones without a response when the context
+// is done, are set in their results.
+func (b *Batch) RunContext(ctx context.Context) ([]Result, error) {
+	results := make([]Result, len(b.ops))
+
+	var cmds []protocol.Command
+	var sent []int
+	for i, op := range b.ops {
+		results[i] = Result{Slot: op.cmd.Slot, Err: op.err}
+		if op.err == nil {
+			cmds = append(cmds, op.cmd)
+			sent = append(sent, i)
+		}
+	}
+
+	if len(cmds) == 0 {
+		return results, nil
+	}
+
+	responses, err := b.client.sendMany(ctx, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, response := range responses {
+		result := &results[sent[j]]
+		if response.Error != nil {
+			result.Err = response.Error
+			continue
+		}
+		result.Value, result.Err = slotData(result.Slot, response.Data)
+	}
+
+	return results, nil
+}
+
+// ReadMany reads several slots in a single round trip
+func (c *Client) ReadMany(slots ...int) ([]Result, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.ReadManyContext(ctx, slots...)
+}
+
+// ReadManyContext reads several slots in a single round trip until the
+// context is done, the results are in the order of the slots
+func (c *Client) ReadManyContext(ctx context.Context, slots ...int) ([]Result, error) {
+	return readMany(ctx, c.Batch(), slots)
+}
+
+// WriteMany writes several slots in a single round trip
+func (c *Client) WriteMany(values map[int]string) ([]Result, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.WriteManyContext(ctx, values)
+}
+
+// WriteManyContext writes several slots in a single round trip until the
+// context is done, the results are sorted by slot
+func (c *Client) WriteManyContext(ctx context.Context, values map[int]string) ([]Result, error) {
+	return writeMany(ctx, c.Batch(), values)
+}
+
+// readMany runs a batch reading the slots
+func readMany(ctx context.Context, batch *Batch, slots []int) ([]Result, error) {
+	for _, slot := range slots {
+		batch.Read(slot)
+	}
+	return batch.RunContext(ctx)
+}
+
+// writeMany runs a batch writing the values in slot order
+func writeMany(ctx context.Context, batch *Batch, values map[int]string) ([]Result, error) {
+	slots := make([]int, 0, len(values))
+	for slot := range values {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+
+	for _, slot := range slots {
+		batch.Write(slot, values[slot])
+	}
+	return batch.RunContext(ctx)
+}
+
+// sendMany sends several commands in a single write and waits for their
+// responses until the context is done
+func (c *Client) sendMany(ctx context.Context, cmds []protocol.Command) ([]Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+
+	start := time.Now()
+	requests := make([]*pendingRequest, len(cmds))
+	for i, cmd := range cmds {
+		line, err := protocol.AppendCommand(nil, cmd)
+		if err != nil {
+			return nil, err
+		}
+		requests[i] = &pendingRequest{cmd: string(line), ch: make(chan Response, 1)}
+	}
+
+	// Register the requests and send the commands, like send does
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return nil, ErrClosed
+	default:
+	}
+	if !c.connected && c.reconnectPolicy.Pending == FailPending {
+		c.mutex.Unlock()
+		return nil, ErrReconnecting
+	}
+	if err := c.enqueue(requests...); err != nil && c.reconnectPolicy.Pending == FailPending {
+		for _, request := range requests {
+			request.abandoned = true
+		}
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("%w: failed to send commands: %w", ErrConnectionLost, err)
+	}
+	c.mutex.Unlock()
+
+	// Nobody waits for the responses after returning
+	defer func() {
+		c.mutex.Lock()
+		for _, request := range requests {
+			request.abandoned = true
+		}
+		c.mutex.Unlock()
+	}()
+
+	responses := make([]Response, len(requests))
+	for i, request := range requests {
+		select {
+		case responses[i] = <-request.ch:
+		case <-ctx.Done():
+			fillResponses(responses[i:], contextError(ctx.Err()))
+			return responses, nil
+		case <-c.done:
+			fillResponses(responses[i:], ErrClosed)
+			return responses, nil
+		}
+	}
+
+	c.logger.Debug("batch completed",
+		slog.Int("commands", len(cmds)),
+		slog.Duration("latency", time.Since(start)),
+	)
+
+	return responses, nil
+}
+
+// fillResponses sets err as the response of the requests left without one
+func fillResponses(responses []Response, err error) {
+	for i := range responses {
+		responses[i] = Response{Error: err}
+	}
+}
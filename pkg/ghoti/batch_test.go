@@ -0,0 +1,165 @@
+package ghoti
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchServer starts a server with a locked slot and a counter
+func newBatchServer(t *testing.T) *ghotitest.Server {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Value: "one"}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Users: map[string]ghotitest.Permission{"service": ghotitest.ReadWrite}}),
+		ghotitest.WithSlot(3, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter, Value: "5"}),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReadMany(t *testing.T) {
+	server := newBatchServer(t)
+	server.SetSlot(4, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Value: "four"})
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	results, err := client.ReadMany(1, 2, 4, 1000)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, Result{Slot: 1, Value: "one"}, results[0])
+	assert.Equal(t, 2, results[1].Slot)
+	assert.True(t, errors.Is(results[1].Err, ErrAuthRequired))
+	assert.Equal(t, Result{Slot: 4, Value: "four"}, results[2])
+	assert.True(t, errors.Is(results[3].Err, ErrInvalidSlot))
+}
+
+func TestWriteMany(t *testing.T) {
+	server := newBatchServer(t)
+
+	client, err := New(server.Addr(), WithAuth("service", "secret"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	results, err := client.WriteMany(map[int]string{
+		3: "2",
+		2: "two",
+		1: "this value is longer than thirty six characters",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.True(t, errors.Is(results[0].Err, ErrDataTooLong))
+	assert.Equal(t, Result{Slot: 2, Value: "two"}, results[1])
+	assert.Equal(t, Result{Slot: 3, Value: "7"}, results[2], "a counter answers its new value")
+
+	assert.Equal(t, "one", server.Value(1))
+	assert.Equal(t, "two", server.Value(2))
+}
+
+func TestBatch(t *testing.T) {
+	server := newBatchServer(t)
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	batch := client.Batch().Write(5, "five").Read(5).Write(3, "1").Read(3)
+	assert.Equal(t, 4, batch.Len())
+
+	results, err := batch.Run()
+	require.NoError(t, err)
+	assert.Equal(t, []Result{
+		{Slot: 5, Value: "five"},
+		{Slot: 5, Value: "five"},
+		{Slot: 3, Value: "6"},
+		{Slot: 3, Value: "6"},
+	}, results)
+
+	results, err = client.Batch().Run()
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestBatchSingleWrite(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buffer := make([]byte, 1024)
+		n, _ := conn.Read(buffer)
+		received <- string(buffer[:n])
+		conn.Write([]byte("v001a\nv002b\nv003c\n"))
+		conn.Read(buffer)
+	}()
+
+	client, err := New(listener.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	results, err := client.ReadMany(1, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "r001\nr002\nr003\n", <-received)
+	assert.Equal(t, []Result{{Slot: 1, Value: "a"}, {Slot: 2, Value: "b"}, {Slot: 3, Value: "c"}}, results)
+}
+
+func TestBatchContextTimeout(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Slot 999 is answered late, the responses before it are kept
+	results, err := client.ReadManyContext(ctx, 7, 999, 8)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Slot: 7, Value: "007"}, results[0])
+	assert.True(t, errors.Is(results[1].Err, ErrTimeout))
+	assert.True(t, errors.Is(results[2].Err, ErrTimeout))
+
+	// The late responses are dropped and the client is still usable
+	value, err := client.Read(4)
+	require.NoError(t, err)
+	assert.Equal(t, "004", value)
+
+	client.Close()
+	_, err = client.ReadMany(1)
+	assert.True(t, errors.Is(err, ErrClosed))
+}
+
+func TestPoolBatch(t *testing.T) {
+	server := newBatchServer(t)
+
+	cfg, err := NewConfig(server.Addr(), WithAuth("service", "secret"))
+	require.NoError(t, err)
+	pool, err := NewPool(cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	_, err = pool.WriteMany(map[int]string{2: "two"})
+	require.NoError(t, err)
+
+	results, err := pool.ReadMany(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Result{{Slot: 1, Value: "one"}, {Slot: 2, Value: "two"}}, results)
+}
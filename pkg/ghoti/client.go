@@ -2,7 +2,10 @@ package ghoti
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
@@ -10,9 +13,15 @@ import (
 	"time"
 
 	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/internal/logging"
 	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
 )
 
+// defaultTimeout is the time Read, Write and Broadcast wait for a response
+// unless the configuration sets another one
+const defaultTimeout = 5 * time.Second
+
 // Response represents a response from the Ghoti server
 type Response struct {
 	Data  string
@@ -22,52 +31,168 @@ type Response struct {
 // BroadcastHandler is a function that handles broadcast messages
 type BroadcastHandler func(slot int, data string)
 
+// pendingRequest is a command waiting for a response from the server. The
+// server answers commands in the order they were sent, so pending requests
+// are kept in a FIFO queue and every response goes to the oldest one.
+type pendingRequest struct {
+	cmd string
+	ch  chan Response
+
+	// abandoned is set when nobody waits for the response anymore. The
+	// request stays queued to keep the order but its response is dropped.
+	abandoned bool
+}
+
 // Client represents a client connection to a Ghoti server
 type Client struct {
 	config           config.Config
 	conn             net.Conn
-	reader           *bufio.Reader
+	decoder          *protocol.Decoder
 	mutex            sync.Mutex
-	pendingRequests  map[int]chan Response
+	pending          []*pendingRequest
 	broadcastHandler BroadcastHandler
+	subscribers      map[int][]*subscriber
+	stateHandler     StateHandler
+	dialer           Dialer
+	logger           *slog.Logger
+	reconnectPolicy  ReconnectPolicy
+	valueCodecs      map[int]protocol.ValueCodec
+	timeout          time.Duration
+	state            ConnectionState
+	connected        bool
+	authenticated    bool
 	done             chan struct{}
+	closeOnce        sync.Once
+	closeErr         error
 	wg               sync.WaitGroup
 }
 
 // NewClient creates a new Client from a configuration
 func NewClient(config config.Config) (*Client, error) {
-	conn, err := net.Dial(config.Protocol(), config.Server())
-	if err != nil {
-		return nil, err
-	}
-
 	client := &Client{
 		config:          config,
-		conn:            conn,
-		reader:          bufio.NewReader(conn),
-		pendingRequests: make(map[int]chan Response),
+		dialer:          dialerFor(config),
+		logger:          logging.Discard(),
+		reconnectPolicy: DefaultReconnectPolicy(),
+		valueCodecs:     make(map[int]protocol.ValueCodec),
+		timeout:         defaultTimeout,
+		state:           StateConnected,
+		connected:       true,
 		done:            make(chan struct{}),
 	}
 
+	// Optional settings the configuration may provide
+	if timeoutConfig, ok := config.(TimeoutConfig); ok && timeoutConfig.Timeout() > 0 {
+		client.timeout = timeoutConfig.Timeout()
+	}
+	if reconnectConfig, ok := config.(ReconnectConfig); ok {
+		client.reconnectPolicy = reconnectConfig.ReconnectPolicy()
+	}
+	if loggerConfig, ok := config.(LoggerConfig); ok && loggerConfig.Logger() != nil {
+		client.logger = loggerConfig.Logger()
+	}
+	if valueCodecConfig, ok := config.(ValueCodecConfig); ok {
+		for slot, codec := range valueCodecConfig.ValueCodecs() {
+			client.valueCodecs[slot] = codec
+		}
+	}
+
+	client.logger = client.logger.With(
+		slog.String("network", config.Protocol()),
+		slog.String("address", config.Server()),
+	)
+
+	conn, err := client.dial()
+	if err != nil {
+		client.logger.Warn("failed to connect", slog.String("error", err.Error()))
+		return nil, err
+	}
+	client.conn = conn
+	client.decoder = client.newDecoder(conn)
+	client.logger.Info("connected")
+
 	// Start the message listener
 	client.wg.Add(1)
 	go client.listen()
 
+	// Authenticate right away if the configuration asks for it
+	if shouldAutoAuth(config) {
+		if err := client.Auth(); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
 	return client, nil
 }
 
-// SetBroadcastHandler sets the handler for broadcast messages
+// shouldAutoAuth tells if the configuration asks to authenticate on connect
+func shouldAutoAuth(c config.Config) bool {
+	autoAuth, ok := c.(config.AutoAuthConfig)
+	return ok && autoAuth.AutoAuth() && c.Auth() != nil
+}
+
+// newDecoder creates the decoder for a connection using the configured buffer size
+func (c *Client) newDecoder(conn net.Conn) *protocol.Decoder {
+	if size := c.config.ReadBufferSize(); size > 0 {
+		return protocol.NewDecoder(bufio.NewReaderSize(conn, size))
+	}
+	return protocol.NewDecoder(conn)
+}
+
+// SetBroadcastHandler sets the handler for broadcast messages. The handler is
+// called from the goroutine reading responses, so Subscribe should be used
+// for handlers that may be slow.
 func (c *Client) SetBroadcastHandler(handler BroadcastHandler) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.broadcastHandler = handler
 }
 
+// SetStateHandler sets the handler notified when the connection state changes.
+// The handler is called from the listener goroutine and must not block.
+func (c *Client) SetStateHandler(handler StateHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stateHandler = handler
+}
+
+// SetReconnectPolicy sets how the client reconnects after losing the connection
+func (c *Client) SetReconnectPolicy(policy ReconnectPolicy) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reconnectPolicy = policy
+}
+
+// State returns the current state of the connection
+func (c *Client) State() ConnectionState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state
+}
+
 // Close closes the connection to the server
 func (c *Client) Close() error {
-	close(c.done)
+	c.shutdown()
 	c.wg.Wait()
-	return c.conn.Close()
+	return c.closeErr
+}
+
+// shutdown stops the client without waiting for the listener to finish, so
+// it is safe to call from the listener goroutine
+func (c *Client) shutdown() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mutex.Lock()
+		c.connected = false
+		c.closeErr = c.conn.Close()
+		c.mutex.Unlock()
+
+		c.closeSubscribers()
+		c.setState(StateClosed)
+		c.logger.Info("connection closed")
+	})
 }
 
 // listen continuously reads messages from the server and processes them
@@ -75,326 +200,393 @@ func (c *Client) listen() {
 	defer c.wg.Done()
 
 	for {
-		select {
-		case <-c.done:
-			return
-		default:
-			line, err := c.reader.ReadString('\n')
-			if err != nil {
-				// Connection closed or error
-				c.handleFatalError(fmt.Errorf("connection error: %w", err))
+		message, err := c.decoder.DecodeMessage()
+		if err != nil {
+			select {
+			case <-c.done:
 				return
+			default:
 			}
 
-			line = strings.TrimSuffix(line, "\n")
-			if len(line) == 0 {
-				continue
+			// Connection lost or out of sync after an invalid line, try to
+			// get a new one
+			if !c.reconnect(err) {
+				c.handleFatalError(fmt.Errorf("connection error: %w", err))
+				return
 			}
-
-			// Process the message
-			c.processMessage(line)
+			continue
 		}
+
+		// Process the message
+		c.processMessage(message)
 	}
 }
 
 // processMessage processes a message received from the server
-func (c *Client) processMessage(message string) {
-	if len(message) == 0 {
-		return
-	}
-
-	messageType := message[0]
-	switch messageType {
-	case 'v': // Value response
-		c.handleValueResponse(message)
-	case 'e': // Error response
-		c.handleErrorResponse(message)
-	case 'a': // Async/broadcast message
-		c.handleBroadcastMessage(message)
+func (c *Client) processMessage(message protocol.Message) {
+	switch message.Kind {
+	case protocol.KindBroadcast:
+		c.handleBroadcastMessage(message.Slot, message.Data)
 	default:
-		c.handleFatalError(fmt.Errorf("unknown message type: %c", messageType))
+		// Values and errors answer the oldest pending request
+		request := c.nextPending()
+		if request == nil {
+			c.handleFatalError(fmt.Errorf("received response with no pending request: %s", message))
+			return
+		}
+
+		request.ch <- Response{Data: message.Data, Error: message.Err()}
 	}
 }
 
-// handleValueResponse processes a value response from the server
-func (c *Client) handleValueResponse(message string) {
-	// Special case for auth responses which don't have a slot
-	if len(message) > 1 && message[1:] == c.config.Auth().User() {
-		// This is an auth response, ignore it
-		return
-	}
+// nextPending removes and returns the oldest pending request, or nil if no
+// request is waiting for a response
+func (c *Client) nextPending() *pendingRequest {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	// Value responses for slot operations have format: v000data
-	if len(message) < 4 {
-		c.handleFatalError(fmt.Errorf("invalid value response format: %s", message))
-		return
+	if len(c.pending) == 0 {
+		return nil
 	}
 
-	// Extract slot number
-	slotStr := message[1:4]
-	slot, err := strconv.Atoi(slotStr)
-	if err != nil {
-		c.handleFatalError(fmt.Errorf("invalid slot number in response: %s", slotStr))
-		return
-	}
+	request := c.pending[0]
+	c.pending[0] = nil
+	c.pending = c.pending[1:]
 
-	// Extract data
-	data := message[4:]
+	return request
+}
 
-	// Forward to waiting request if any
+// failPending sends the response to every pending request and forgets them
+func (c *Client) failPending(response Response) {
 	c.mutex.Lock()
-	ch, exists := c.pendingRequests[slot]
-	c.mutex.Unlock()
+	defer c.mutex.Unlock()
 
-	if exists {
-		ch <- Response{Data: data}
-	} else {
-		// Unexpected response
-		c.handleFatalError(fmt.Errorf("received response for slot %d with no pending request", slot))
+	for _, request := range c.pending {
+		request.ch <- response
 	}
+	c.pending = nil
 }
 
-// handleErrorResponse processes an error response from the server
-func (c *Client) handleErrorResponse(message string) {
-	// Error responses have format: e000
-	if len(message) < 4 {
-		c.handleFatalError(fmt.Errorf("invalid error response format: %s", message))
-		return
+// enqueue adds requests to the pending queue and sends their commands in a
+// single write if the client is connected. It must be called with the mutex
+// held so commands are written in the same order they are queued.
+func (c *Client) enqueue(requests ...*pendingRequest) error {
+	c.pending = append(c.pending, requests...)
+
+	if !c.connected {
+		return nil
 	}
 
-	errorCode := message[1:4]
-	
-	// For auth errors, we don't have a slot to forward to
-	if errorCode == "004" || errorCode == "005" {
-		// Authentication errors, log them
-		fmt.Printf("Authentication error: %s\n", errorCode)
-		return
+	if len(requests) == 1 {
+		_, err := c.conn.Write([]byte(requests[0].cmd))
+		return err
 	}
 
-	// For other errors, we need to determine which request this is for
-	// This is a simplification - in a real implementation, you'd need to track
-	// which request this error is for
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	
-	// Since we don't know which slot this error is for, we'll just forward it to all pending requests
-	// In a real implementation, you'd want to be more precise
-	for slot, ch := range c.pendingRequests {
-		ch <- Response{Error: model.NewGhotiError(errorCode)}
-		delete(c.pendingRequests, slot)
+	var buffer strings.Builder
+	for _, request := range requests {
+		buffer.WriteString(request.cmd)
 	}
+	_, err := c.conn.Write([]byte(buffer.String()))
+	return err
 }
 
 // handleBroadcastMessage processes a broadcast message from the server
-func (c *Client) handleBroadcastMessage(message string) {
-	// Broadcast messages have format: a000data
-	if len(message) < 4 {
-		c.handleFatalError(fmt.Errorf("invalid broadcast message format: %s", message))
-		return
-	}
-
-	// Extract slot number
-	slotStr := message[1:4]
-	slot, err := strconv.Atoi(slotStr)
-	if err != nil {
-		c.handleFatalError(fmt.Errorf("invalid slot number in broadcast: %s", slotStr))
-		return
-	}
-
-	// Extract data
-	data := message[4:]
-
+func (c *Client) handleBroadcastMessage(slot int, data string) {
 	// Call the broadcast handler if set
 	c.mutex.Lock()
 	handler := c.broadcastHandler
 	c.mutex.Unlock()
 
-	if handler != nil {
-		handler(slot, data)
+	subscribed := c.publish(Message{Slot: slot, Data: data})
+
+	if handler == nil {
+		if !subscribed {
+			c.logger.Debug("broadcast dropped, no handler or subscription", slog.Int("slot", slot))
+		}
+		return
 	}
+
+	handler(slot, data)
 }
 
 // handleFatalError handles a fatal error in the client
 func (c *Client) handleFatalError(err error) {
 	// For critical errors, close the connection
-	fmt.Printf("Fatal client error: %v\n", err)
-	c.Close()
+	c.logger.Error("fatal client error, closing connection", slog.String("error", err.Error()))
+	c.shutdown()
 }
 
-// Auth authenticates with the server using the configured credentials
+// Auth authenticates with the server using the configured credentials. The
+// credentials are sent again every time the client reconnects.
 func (c *Client) Auth() error {
-	// Send user command
-	userCmd := fmt.Sprintf("u%s\n", c.config.Auth().User())
-	_, err := c.conn.Write([]byte(userCmd))
-	if err != nil {
-		return fmt.Errorf("failed to send user command: %w", err)
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.AuthContext(ctx)
+}
+
+// AuthContext authenticates with the server using the configured credentials,
+// waiting for the server to accept them until the context is done. Invalid
+// credentials are reported with a *model.GhotiError.
+func (c *Client) AuthContext(ctx context.Context) error {
+	auth := c.config.Auth()
+	if auth == nil {
+		return fmt.Errorf("%w: no credentials configured", ErrAuthRequired)
 	}
 
-	// Wait a bit for the server to process
-	time.Sleep(100 * time.Millisecond)
+	// Send user command
+	if _, err := c.roundTrip(ctx, protocol.User(auth.User())); err != nil {
+		return c.authFailed(auth, authError("user", err))
+	}
 
 	// Send password command
-	passCmd := fmt.Sprintf("p%s\n", c.config.Auth().Pass())
-	_, err = c.conn.Write([]byte(passCmd))
-	if err != nil {
-		return fmt.Errorf("failed to send password command: %w", err)
+	if _, err := c.roundTrip(ctx, protocol.Pass(auth.Pass())); err != nil {
+		return c.authFailed(auth, authError("password", err))
 	}
 
-	// Wait a bit for the server to process
-	time.Sleep(100 * time.Millisecond)
+	c.mutex.Lock()
+	c.authenticated = true
+	c.mutex.Unlock()
+
+	c.logger.Info("authenticated", slog.String("user", auth.User()))
 
 	return nil
 }
 
-// Read reads the value from a slot
-func (c *Client) Read(slot int) (string, error) {
-	if slot < 0 || slot > 999 {
-		return "", fmt.Errorf("invalid slot number: %d", slot)
+// authFailed logs a failed authentication and returns its error
+func (c *Client) authFailed(auth AuthConfig, err error) error {
+	attrs := append([]slog.Attr{slog.String("user", auth.User())}, logging.Error(err)...)
+	c.logger.LogAttrs(context.Background(), slog.LevelWarn, "authentication failed", attrs...)
+	return err
+}
+
+// authError returns the server error as is so callers get the
+// *model.GhotiError, other errors are wrapped with the failed step
+func authError(step string, err error) error {
+	var ghotiErr *model.GhotiError
+	if errors.As(err, &ghotiErr) {
+		return ghotiErr
+	}
+	return fmt.Errorf("failed to send %s command: %w", step, err)
+}
+
+// timeoutContext returns the context used by the methods that don't take one
+func (c *Client) timeoutContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// requestTimeout returns the time to wait for a response when no context is given
+func (c *Client) requestTimeout() time.Duration {
+	return c.timeout
+}
+
+// log returns the logger of the client
+func (c *Client) log() *slog.Logger {
+	return c.logger
+}
+
+// roundTrip validates a command, sends it and waits for the server response
+// until the context is done
+func (c *Client) roundTrip(ctx context.Context, cmd protocol.Command) (string, error) {
+	line, err := protocol.AppendCommand(nil, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return c.roundTripLine(ctx, string(line))
+}
+
+// roundTripLine sends a protocol line, with its line break, and waits for the
+// server response until the context is done
+func (c *Client) roundTripLine(ctx context.Context, line string) (string, error) {
+	start := time.Now()
+	response, err := c.send(ctx, line)
+
+	attrs := append(logging.Command(line), slog.Duration("latency", time.Since(start)))
+	attrs = append(attrs, logging.Error(err)...)
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "request completed", attrs...)
+
+	return response, err
+}
+
+// send sends a command and waits for the server response until the context is done
+func (c *Client) send(ctx context.Context, cmd string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", contextError(err)
 	}
 
 	// Create a channel to receive the response
-	responseCh := make(chan Response, 1)
+	request := &pendingRequest{cmd: cmd, ch: make(chan Response, 1)}
 
-	// Register the pending request
+	// Register the pending request and send the command. While reconnecting
+	// the command is either rejected or sent once the connection is back,
+	// depending on the reconnect policy.
 	c.mutex.Lock()
-	c.pendingRequests[slot] = responseCh
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return "", ErrClosed
+	default:
+	}
+	if !c.connected && c.reconnectPolicy.Pending == FailPending {
+		c.mutex.Unlock()
+		return "", ErrReconnecting
+	}
+	if err := c.enqueue(request); err != nil && c.reconnectPolicy.Pending == FailPending {
+		request.abandoned = true
+		c.mutex.Unlock()
+		return "", fmt.Errorf("%w: failed to send command: %w", ErrConnectionLost, err)
+	}
 	c.mutex.Unlock()
 
-	// Clean up when done
+	// Nobody waits for the response after returning
 	defer func() {
 		c.mutex.Lock()
-		delete(c.pendingRequests, slot)
+		request.abandoned = true
 		c.mutex.Unlock()
 	}()
 
-	// Send the read command
-	cmd := fmt.Sprintf("r%03d\n", slot)
-	_, err := c.conn.Write([]byte(cmd))
-	if err != nil {
-		return "", fmt.Errorf("failed to send read command: %w", err)
-	}
-
-	// Wait for the response with a timeout
+	// Wait for the response until the context is done
 	select {
-	case response := <-responseCh:
+	case response := <-request.ch:
 		if response.Error != nil {
 			return "", response.Error
 		}
 		return response.Data, nil
-	case <-time.After(5 * time.Second):
-		return "", fmt.Errorf("timeout waiting for response")
+	case <-ctx.Done():
+		return "", contextError(ctx.Err())
 	case <-c.done:
-		return "", fmt.Errorf("client closed")
+		return "", ErrClosed
 	}
 }
 
-// Write writes a value to a slot
-func (c *Client) Write(slot int, data string) error {
-	if slot < 0 || slot > 999 {
-		return fmt.Errorf("invalid slot number: %d", slot)
+// slotRoundTrip sends a slot command and returns the data of the response,
+// which has format: v000data
+func (c *Client) slotRoundTrip(ctx context.Context, cmd protocol.Command) (string, error) {
+	response, err := c.roundTrip(ctx, cmd)
+	if err != nil {
+		return "", err
 	}
 
-	if len(data) > 36 {
-		return fmt.Errorf("data too long: maximum length is 36 characters")
+	return slotData(cmd.Slot, response)
+}
+
+// slotData checks a response to a slot command is for the slot and returns
+// its data
+func slotData(slot int, response string) (string, error) {
+	return protocol.Value(response).SlotData(slot)
+}
+
+// contextError wraps the error of a finished context so callers can tell a
+// timeout or a cancellation from an error returned by the server
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
 	}
+	return fmt.Errorf("%w: %w", ErrCanceled, err)
+}
 
-	// Create a channel to receive the response
-	responseCh := make(chan Response, 1)
+// Read reads the value from a slot
+func (c *Client) Read(slot int) (string, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.ReadContext(ctx, slot)
+}
 
-	// Register the pending request
-	c.mutex.Lock()
-	c.pendingRequests[slot] = responseCh
-	c.mutex.Unlock()
+// ReadContext reads the value from a slot, waiting for the response until the
+// context is done
+func (c *Client) ReadContext(ctx context.Context, slot int) (string, error) {
+	// Send the read command
+	return c.slotRoundTrip(ctx, protocol.Read(slot))
+}
 
-	// Clean up when done
-	defer func() {
-		c.mutex.Lock()
-		delete(c.pendingRequests, slot)
-		c.mutex.Unlock()
-	}()
+// Write writes a value to a slot
+func (c *Client) Write(slot int, data string) error {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.WriteContext(ctx, slot, data)
+}
 
-	// Send the write command
-	cmd := fmt.Sprintf("w%03d%s\n", slot, data)
-	_, err := c.conn.Write([]byte(cmd))
-	if err != nil {
-		return fmt.Errorf("failed to send write command: %w", err)
-	}
+// WriteContext writes a value to a slot, waiting for the response until the
+// context is done
+func (c *Client) WriteContext(ctx context.Context, slot int, data string) error {
+	_, err := c.write(ctx, slot, data)
+	return err
+}
 
-	// Wait for the response with a timeout
-	select {
-	case response := <-responseCh:
-		return response.Error
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timeout waiting for response")
-	case <-c.done:
-		return fmt.Errorf("client closed")
-	}
+// write sends a write command and returns the data of the response, which
+// depends on the type of the slot
+func (c *Client) write(ctx context.Context, slot int, data string) (string, error) {
+	// Send the write command
+	return c.slotRoundTrip(ctx, protocol.Write(slot, data))
 }
 
 // Broadcast sends a message to all connected clients
 func (c *Client) Broadcast(slot int, data string) (int, int, int, error) {
-	if slot < 0 || slot > 999 {
-		return 0, 0, 0, fmt.Errorf("invalid slot number: %d", slot)
-	}
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.BroadcastContext(ctx, slot, data)
+}
 
-	if len(data) > 36 {
-		return 0, 0, 0, fmt.Errorf("data too long: maximum length is 36 characters")
+// BroadcastContext sends a message to all connected clients, waiting for the
+// response until the context is done
+func (c *Client) BroadcastContext(ctx context.Context, slot int, data string) (int, int, int, error) {
+	// Broadcast uses the write command
+	response, err := c.write(ctx, slot, data)
+	if err != nil {
+		return 0, 0, 0, err
 	}
 
-	// Create a channel to receive the response
-	responseCh := make(chan Response, 1)
-
-	// Register the pending request
-	c.mutex.Lock()
-	c.pendingRequests[slot] = responseCh
-	c.mutex.Unlock()
+	// Parse the response format: a/b/c
+	parts := strings.Split(response, "/")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("%w: unexpected broadcast format: %s", ErrInvalidResponse, response)
+	}
 
-	// Clean up when done
-	defer func() {
-		c.mutex.Lock()
-		delete(c.pendingRequests, slot)
-		c.mutex.Unlock()
-	}()
+	received, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: invalid received count: %s", ErrInvalidResponse, parts[0])
+	}
 
-	// Send the write command (broadcast uses the write command)
-	cmd := fmt.Sprintf("w%03d%s\n", slot, data)
-	_, err := c.conn.Write([]byte(cmd))
+	total, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to send broadcast command: %w", err)
+		return 0, 0, 0, fmt.Errorf("%w: invalid total count: %s", ErrInvalidResponse, parts[1])
 	}
 
-	// Wait for the response with a timeout
-	select {
-	case response := <-responseCh:
-		if response.Error != nil {
-			return 0, 0, 0, response.Error
-		}
+	failed, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: invalid failed count: %s", ErrInvalidResponse, parts[2])
+	}
 
-		// Parse the response format: a/b/c
-		parts := strings.Split(response.Data, "/")
-		if len(parts) != 3 {
-			return 0, 0, 0, fmt.Errorf("invalid broadcast response format: %s", response.Data)
-		}
+	return received, total, failed, nil
+}
 
-		received, err := strconv.Atoi(parts[0])
-		if err != nil {
-			return 0, 0, 0, fmt.Errorf("invalid received count: %s", parts[0])
-		}
+// Raw sends a protocol line, such as r005, and returns the response line
+func (c *Client) Raw(line string) (string, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.RawContext(ctx, line)
+}
 
-		total, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return 0, 0, 0, fmt.Errorf("invalid total count: %s", parts[1])
-		}
+// RawContext sends a protocol line, such as r005, and returns the response
+// line, such as v005data, waiting for it until the context is done. Error
+// responses are returned as a *model.GhotiError. Lines that are not valid
+// commands are rejected without sending them, since the server may not answer
+// them and the responses would no longer match the requests. It is meant for
+// debugging, credentials sent this way are not used when reconnecting.
+func (c *Client) RawContext(ctx context.Context, line string) (string, error) {
+	if line == "" || strings.ContainsAny(line, "\r\n") {
+		return "", fmt.Errorf("%w: protocol line must be a single non empty line", ErrInvalidCommand)
+	}
 
-		failed, err := strconv.Atoi(parts[2])
-		if err != nil {
-			return 0, 0, 0, fmt.Errorf("invalid failed count: %s", parts[2])
-		}
+	cmd, err := protocol.ParseCommand(line)
+	if err != nil {
+		return "", err
+	}
 
-		return received, total, failed, nil
-	case <-time.After(5 * time.Second):
-		return 0, 0, 0, fmt.Errorf("timeout waiting for response")
-	case <-c.done:
-		return 0, 0, 0, fmt.Errorf("client closed")
+	response, err := c.roundTrip(ctx, cmd)
+	if err != nil {
+		return "", err
 	}
-}
\ No newline at end of file
+
+	return "v" + response, nil
+}
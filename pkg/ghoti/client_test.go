@@ -0,0 +1,234 @@
+package ghoti
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig is a configuration pointing to a local test server
+type testConfig struct {
+	server   string
+	pass     string
+	autoAuth bool
+}
+
+func (c *testConfig) Protocol() string        { return "tcp" }
+func (c *testConfig) Server() string          { return c.server }
+func (c *testConfig) ReadBufferSize() int     { return 8 * 1024 }
+func (c *testConfig) Auth() config.AuthConfig { return c }
+func (c *testConfig) AutoAuth() bool          { return c.autoAuth }
+func (c *testConfig) User() string            { return "test_user" }
+
+func (c *testConfig) Pass() string {
+	if c.pass == "" {
+		return "test_pass"
+	}
+	return c.pass
+}
+
+// echoServer answers read commands with the slot number as value, write
+// commands with the written data and any other command with the argument.
+// Passwords other than the test one are rejected.
+// Commands on slot 999 are answered late. Each accepted connection is handed
+// to the conns channel.
+func echoServer(t *testing.T) (net.Listener, chan net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	return listener, serveEcho(t, listener)
+}
+
+// serveEcho runs the echo server on the given listener
+func serveEcho(t *testing.T, listener net.Listener) chan net.Conn {
+	t.Cleanup(func() { listener.Close() })
+
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+
+			go func() {
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					line = strings.TrimSuffix(line, "\n")
+					switch {
+					case line[0] == 'p' && line[1:] != "test_pass":
+						conn.Write([]byte("e005\n"))
+					case line[0] != 'r' && line[0] != 'w':
+						conn.Write([]byte("v" + line[1:] + "\n"))
+					case line[1:4] == "999":
+						time.Sleep(200 * time.Millisecond)
+						conn.Write([]byte("v" + line[1:] + "\n"))
+					case line[0] == 'r':
+						conn.Write([]byte("v" + line[1:4] + line[1:4] + "\n"))
+					default:
+						conn.Write([]byte("v" + line[1:] + "\n"))
+					}
+				}
+			}()
+		}
+	}()
+
+	return conns
+}
+
+func TestClientReadContext(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.ReadContext(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, "012", value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ReadContext(ctx, 12)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestClientWriteContextTimeout(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = client.WriteContext(ctx, 999, "data")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// The late response is dropped and the client is still usable
+	value, err := client.Read(4)
+	require.NoError(t, err)
+	assert.Equal(t, "004", value)
+}
+
+func TestClientConcurrentRequestsOnSameSlot(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if i%2 == 0 {
+				value, err := client.Read(5)
+				assert.NoError(t, err)
+				assert.Equal(t, "005", value)
+				return
+			}
+
+			data := strconv.Itoa(i)
+			value, err := client.slotRoundTrip(context.Background(), protocol.Write(5, data))
+			assert.NoError(t, err)
+			assert.Equal(t, data, value)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestClientAuth(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.NoError(t, client.Auth())
+}
+
+func TestClientAuthInvalidCredentials(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String(), pass: "wrong"})
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.Auth()
+	var ghotiErr *model.GhotiError
+	require.True(t, errors.As(err, &ghotiErr))
+	assert.Equal(t, "005", ghotiErr.Code)
+
+	// The client is still usable afterwards
+	value, err := client.Read(4)
+	require.NoError(t, err)
+	assert.Equal(t, "004", value)
+}
+
+func TestNewClientAutoAuth(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String(), autoAuth: true})
+	require.NoError(t, err)
+	client.Close()
+
+	_, err = NewClient(&testConfig{server: listener.Addr().String(), pass: "wrong", autoAuth: true})
+	var ghotiErr *model.GhotiError
+	require.True(t, errors.As(err, &ghotiErr))
+	assert.Equal(t, "005", ghotiErr.Code)
+}
+
+func TestClientRaw(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	response, err := client.Raw("r012")
+	require.NoError(t, err)
+	assert.Equal(t, "v012012", response)
+
+	_, err = client.Raw("pwrong")
+	var ghotiErr *model.GhotiError
+	require.True(t, errors.As(err, &ghotiErr))
+	assert.Equal(t, "005", ghotiErr.Code)
+
+	// Invalid commands are not sent
+	_, err = client.Raw("x001")
+	assert.True(t, errors.Is(err, ErrInvalidCommand))
+	_, err = client.Raw("rabc")
+	assert.True(t, errors.Is(err, ErrInvalidSlot))
+	_, err = client.Raw("w001" + strings.Repeat("x", 37))
+	assert.True(t, errors.Is(err, ErrDataTooLong))
+
+	response, err = client.Raw("r013")
+	require.NoError(t, err)
+	assert.Equal(t, "v013013", response)
+
+	_, err = client.Raw("r001\nr002")
+	assert.True(t, errors.Is(err, ErrInvalidCommand))
+	_, err = client.Raw("")
+	assert.True(t, errors.Is(err, ErrInvalidCommand))
+}
@@ -0,0 +1,407 @@
+package ghoti
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/internal/logging"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// ClientConfig is the configuration used by NewClient. Config implements it,
+// but it can also be implemented to read the configuration from any source.
+type ClientConfig = config.Config
+
+// AuthConfig provides the credentials used to authenticate with the server
+type AuthConfig = config.AuthConfig
+
+// AutoAuthConfig can be implemented by a ClientConfig to have the client
+// authenticate as soon as it connects
+type AutoAuthConfig = config.AutoAuthConfig
+
+// TimeoutConfig can be implemented by a ClientConfig to change how long the
+// client waits for a response when the caller gives no context
+type TimeoutConfig = config.TimeoutConfig
+
+// LoggerConfig can be implemented by a ClientConfig to have the client write
+// its logs with the given logger instead of discarding them
+type LoggerConfig = config.LoggerConfig
+
+// ReconnectConfig can be implemented by a ClientConfig to change how the
+// client reconnects after losing the connection
+type ReconnectConfig interface {
+	ReconnectPolicy() ReconnectPolicy
+}
+
+// ValueCodecConfig can be implemented by a ClientConfig to set the codecs
+// used by WriteBytes and ReadBytes on some slots
+type ValueCodecConfig interface {
+	ValueCodecs() map[int]protocol.ValueCodec
+}
+
+const (
+	// DefaultNetwork is the network used to reach the server
+	DefaultNetwork = "tcp"
+	// DefaultReadBufferSize is the size of the buffer used to read responses
+	DefaultReadBufferSize = 8 * 1024
+	// DefaultTimeout is the time to wait for a response when no context is given
+	DefaultTimeout = defaultTimeout
+)
+
+// Config is a client configuration, usually built with NewConfig and options
+type Config struct {
+	// Network is the network used to reach the server, such as "tcp" or "unix"
+	Network string
+	// Address is the address of the server
+	Address string
+	// BufferSize is the size of the buffer used to read responses
+	BufferSize int
+	// RequestTimeout is the time to wait for a response when no context is given
+	RequestTimeout time.Duration
+	// User is the user to authenticate as, the client authenticates on
+	// connect when it is set
+	User string
+	// Password is the password of the user
+	Password string
+	// Reconnect configures how the client reconnects after losing the connection
+	Reconnect ReconnectPolicy
+	// TLS enables TLS connections to the server when it is set
+	TLS *TLSConfig
+	// CustomDialer replaces the dialer used to connect to the server, it
+	// takes precedence over TLS
+	CustomDialer Dialer
+	// Log receives the logs of the client, they are discarded when it is nil
+	Log *slog.Logger
+	// SlotValueCodecs are the codecs used by WriteBytes and ReadBytes on each
+	// slot, protocol.Text is used for the slots without one
+	SlotValueCodecs map[int]protocol.ValueCodec
+
+	// tlsEnabled is the tls.enabled setting while loading, nil if not set
+	tlsEnabled *bool
+}
+
+// Option configures a Config
+type Option func(*Config) error
+
+// NewConfig creates a configuration for the server at the given address
+func NewConfig(addr string, opts ...Option) (*Config, error) {
+	cfg := defaultConfig()
+	cfg.Address = addr
+
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// defaultConfig returns a configuration with the default values and no address
+func defaultConfig() *Config {
+	return &Config{
+		Network:        DefaultNetwork,
+		BufferSize:     DefaultReadBufferSize,
+		RequestTimeout: DefaultTimeout,
+		Reconnect:      DefaultReconnectPolicy(),
+	}
+}
+
+// New creates a new Client connected to the server at the given address
+func New(addr string, opts ...Option) (*Client, error) {
+	cfg, err := NewConfig(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(cfg)
+}
+
+// WithNetwork sets the network used to reach the server
+func WithNetwork(network string) Option {
+	return func(c *Config) error {
+		if err := validateNetwork(network); err != nil {
+			return err
+		}
+		c.Network = network
+		return nil
+	}
+}
+
+// WithAuth sets the credentials used to authenticate on connect
+func WithAuth(user, pass string) Option {
+	return func(c *Config) error {
+		if err := validateCredentials(user, pass); err != nil {
+			return err
+		}
+		c.User = user
+		c.Password = pass
+		return nil
+	}
+}
+
+// WithTimeout sets the time to wait for a response when no context is given
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) error {
+		if timeout <= 0 {
+			return fmt.Errorf("%w: timeout must be positive: %s", ErrInvalidConfig, timeout)
+		}
+		c.RequestTimeout = timeout
+		return nil
+	}
+}
+
+// WithReadBufferSize sets the size of the buffer used to read responses
+func WithReadBufferSize(size int) Option {
+	return func(c *Config) error {
+		if size <= 0 {
+			return fmt.Errorf("%w: read buffer size must be positive: %d", ErrInvalidConfig, size)
+		}
+		c.BufferSize = size
+		return nil
+	}
+}
+
+// WithReconnectPolicy sets how the client reconnects after losing the connection
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(c *Config) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.Reconnect = policy
+		return nil
+	}
+}
+
+// WithTLS enables TLS connections to the server
+func WithTLS(config TLSConfig) Option {
+	return func(c *Config) error {
+		if _, err := config.Build(); err != nil {
+			return err
+		}
+		c.TLS = &config
+		return nil
+	}
+}
+
+// WithDialer sets the dialer used to connect to the server
+func WithDialer(dialer Dialer) Option {
+	return func(c *Config) error {
+		if dialer == nil {
+			return fmt.Errorf("%w: dialer is nil", ErrInvalidConfig)
+		}
+		c.CustomDialer = dialer
+		return nil
+	}
+}
+
+// WithLogger sets the logger that receives the logs of the client
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) error {
+		c.Log = logger
+		return nil
+	}
+}
+
+// WithValueCodec sets the codec used by WriteBytes and ReadBytes on a slot
+func WithValueCodec(slot int, codec protocol.ValueCodec) Option {
+	return func(c *Config) error {
+		if err := validateValueCodec(slot, codec); err != nil {
+			return err
+		}
+
+		if c.SlotValueCodecs == nil {
+			c.SlotValueCodecs = make(map[int]protocol.ValueCodec)
+		}
+		c.SlotValueCodecs[slot] = codec
+		return nil
+	}
+}
+
+// Validate checks every value of the configuration
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("%w: address is required", ErrInvalidConfig)
+	}
+
+	if err := validateNetwork(c.Network); err != nil {
+		return err
+	}
+
+	if c.BufferSize <= 0 {
+		return fmt.Errorf("%w: read buffer size must be positive: %d", ErrInvalidConfig, c.BufferSize)
+	}
+
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("%w: timeout must be positive: %s", ErrInvalidConfig, c.RequestTimeout)
+	}
+
+	if err := validateCredentials(c.User, c.Password); err != nil {
+		return err
+	}
+
+	if c.TLS != nil && c.CustomDialer == nil {
+		if _, err := c.TLS.Build(); err != nil {
+			return err
+		}
+	}
+
+	for slot, codec := range c.SlotValueCodecs {
+		if err := validateValueCodec(slot, codec); err != nil {
+			return err
+		}
+	}
+
+	return c.Reconnect.validate()
+}
+
+// Protocol returns the network used to reach the server
+func (c *Config) Protocol() string {
+	return c.Network
+}
+
+// Server returns the address of the server
+func (c *Config) Server() string {
+	return c.Address
+}
+
+// ReadBufferSize returns the size of the buffer used to read responses
+func (c *Config) ReadBufferSize() int {
+	return c.BufferSize
+}
+
+// Auth returns the configured credentials, or nil if there are none
+func (c *Config) Auth() AuthConfig {
+	if c.User == "" {
+		return nil
+	}
+	return &credentials{user: c.User, pass: c.Password}
+}
+
+// AutoAuth tells the client to authenticate on connect when there are credentials
+func (c *Config) AutoAuth() bool {
+	return c.User != ""
+}
+
+// Timeout returns the time to wait for a response when no context is given
+func (c *Config) Timeout() time.Duration {
+	return c.RequestTimeout
+}
+
+// ReconnectPolicy returns how the client reconnects after losing the connection
+func (c *Config) ReconnectPolicy() ReconnectPolicy {
+	return c.Reconnect
+}
+
+// Dialer returns the dialer used to connect to the server
+func (c *Config) Dialer() Dialer {
+	if c.CustomDialer != nil {
+		return c.CustomDialer
+	}
+
+	if c.TLS != nil {
+		config, err := c.TLS.Build()
+		if err != nil {
+			return DialerFunc(func(context.Context, string, string) (net.Conn, error) {
+				return nil, err
+			})
+		}
+		return &tls.Dialer{Config: config}
+	}
+
+	return &net.Dialer{}
+}
+
+// Logger returns the logger that receives the logs of the client
+func (c *Config) Logger() *slog.Logger {
+	return c.Log
+}
+
+// ValueCodecs returns the codecs used by WriteBytes and ReadBytes on each slot
+func (c *Config) ValueCodecs() map[int]protocol.ValueCodec {
+	return c.SlotValueCodecs
+}
+
+// LogValue describes the configuration in the logs with the password redacted
+func (c *Config) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("network", c.Network),
+		slog.String("address", c.Address),
+		slog.Duration("timeout", c.RequestTimeout),
+		slog.Bool("tls", c.TLS != nil),
+	}
+
+	if c.User != "" {
+		attrs = append(attrs, slog.String("user", c.User), slog.String("password", logging.Redacted))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// credentials is the AuthConfig returned by Config
+type credentials struct {
+	user string
+	pass string
+}
+
+func (a *credentials) User() string {
+	return a.user
+}
+
+func (a *credentials) Pass() string {
+	return a.pass
+}
+
+// LogValue describes the credentials in the logs with the password redacted
+func (a *credentials) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("user", a.user),
+		slog.String("password", logging.Redacted),
+	)
+}
+
+// validateNetwork checks the network is one the client can dial
+func validateNetwork(network string) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported network: %q", ErrInvalidConfig, network)
+	}
+}
+
+// validateValueCodec checks a codec can be used on a slot
+func validateValueCodec(slot int, codec protocol.ValueCodec) error {
+	if slot < 0 || slot > protocol.MaxSlot {
+		return fmt.Errorf("%w: codec for invalid slot %d", ErrInvalidConfig, slot)
+	}
+
+	if codec == nil {
+		return fmt.Errorf("%w: codec for slot %d is nil", ErrInvalidConfig, slot)
+	}
+
+	return nil
+}
+
+// validateCredentials checks the credentials can be sent in a command
+func validateCredentials(user, pass string) error {
+	if user == "" && pass != "" {
+		return fmt.Errorf("%w: password given without user", ErrInvalidConfig)
+	}
+
+	if strings.ContainsAny(user, "\r\n") || strings.ContainsAny(pass, "\r\n") {
+		return fmt.Errorf("%w: line breaks are not allowed in credentials", ErrInvalidConfig)
+	}
+
+	return nil
+}
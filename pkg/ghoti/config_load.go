@@ -0,0 +1,374 @@
+package ghoti
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables read by LoadConfig.
+// Every file key maps to a variable by upper casing it and replacing dots with
+// underscores, for example auth.user is read from GHOTI_AUTH_USER.
+const EnvPrefix = "GHOTI_"
+
+// setting is a configuration key that can be loaded from a file or the environment
+type setting struct {
+	key   string
+	apply func(c *Config, value string) error
+}
+
+// shown returns the value of the setting as it is shown in errors, with
+// the password redacted
+func (s setting) shown(value string) string {
+	if s.key == "auth.pass" {
+		return logging.Redacted
+	}
+	return strconv.Quote(value)
+}
+
+// settings lists every key that can be loaded, using the names of the file format
+var settings = []setting{
+	{"protocol", func(c *Config, value string) error {
+		return WithNetwork(value)(c)
+	}},
+	{"server", func(c *Config, value string) error {
+		if value == "" {
+			return fmt.Errorf("address is required")
+		}
+		c.Address = value
+		return nil
+	}},
+	{"read_buffer_size", func(c *Config, value string) error {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		return WithReadBufferSize(size)(c)
+	}},
+	{"timeout", func(c *Config, value string) error {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		return WithTimeout(timeout)(c)
+	}},
+	{"auth.user", func(c *Config, value string) error {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("line breaks are not allowed")
+		}
+		c.User = value
+		return nil
+	}},
+	{"auth.pass", func(c *Config, value string) error {
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("line breaks are not allowed")
+		}
+		c.Password = value
+		return nil
+	}},
+	{"reconnect.disabled", func(c *Config, value string) error {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.Reconnect.Disabled = disabled
+		return nil
+	}},
+	{"reconnect.initial_backoff", func(c *Config, value string) error {
+		return parseDuration(value, &c.Reconnect.InitialBackoff)
+	}},
+	{"reconnect.max_backoff", func(c *Config, value string) error {
+		return parseDuration(value, &c.Reconnect.MaxBackoff)
+	}},
+	{"reconnect.multiplier", func(c *Config, value string) error {
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		c.Reconnect.Multiplier = multiplier
+		return c.Reconnect.validate()
+	}},
+	{"reconnect.jitter", func(c *Config, value string) error {
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		c.Reconnect.Jitter = jitter
+		return c.Reconnect.validate()
+	}},
+	{"reconnect.max_attempts", func(c *Config, value string) error {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		c.Reconnect.MaxAttempts = attempts
+		return c.Reconnect.validate()
+	}},
+	{"reconnect.pending", func(c *Config, value string) error {
+		switch value {
+		case "fail":
+			c.Reconnect.Pending = FailPending
+		case "retry":
+			c.Reconnect.Pending = RetryPending
+		default:
+			return fmt.Errorf("must be fail or retry")
+		}
+		return nil
+	}},
+	{"tls.enabled", func(c *Config, value string) error {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.tlsEnabled = &enabled
+		return nil
+	}},
+	{"tls.ca_file", func(c *Config, value string) error {
+		c.tlsConfig().CAFile = value
+		return nil
+	}},
+	{"tls.cert_file", func(c *Config, value string) error {
+		c.tlsConfig().CertFile = value
+		return nil
+	}},
+	{"tls.key_file", func(c *Config, value string) error {
+		c.tlsConfig().KeyFile = value
+		return nil
+	}},
+	{"tls.server_name", func(c *Config, value string) error {
+		c.tlsConfig().ServerName = value
+		return nil
+	}},
+	{"tls.min_version", func(c *Config, value string) error {
+		switch value {
+		case "1.0":
+			c.tlsConfig().MinVersion = tls.VersionTLS10
+		case "1.1":
+			c.tlsConfig().MinVersion = tls.VersionTLS11
+		case "1.2":
+			c.tlsConfig().MinVersion = tls.VersionTLS12
+		case "1.3":
+			c.tlsConfig().MinVersion = tls.VersionTLS13
+		default:
+			return fmt.Errorf("must be 1.0, 1.1, 1.2 or 1.3")
+		}
+		return nil
+	}},
+	{"tls.insecure_skip_verify", func(c *Config, value string) error {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		c.tlsConfig().InsecureSkipVerify = insecure
+		return nil
+	}},
+}
+
+// tlsConfig returns the TLS configuration, creating it if there is none.
+// Whether TLS stays enabled is decided by resolveTLS once everything is
+// loaded.
+func (c *Config) tlsConfig() *TLSConfig {
+	if c.TLS == nil {
+		c.TLS = &TLSConfig{}
+	}
+	return c.TLS
+}
+
+// resolveTLS enables TLS when tls.enabled is true, or when it is not set and
+// a CA, a client certificate or a server name is configured. Other settings
+// such as tls.insecure_skip_verify don't enable it on their own.
+func (c *Config) resolveTLS() {
+	enabled := c.TLS != nil && (c.TLS.CAFile != "" || c.TLS.CertFile != "" || c.TLS.ServerName != "")
+	if c.tlsEnabled != nil {
+		enabled = *c.tlsEnabled
+		c.tlsEnabled = nil
+	}
+
+	switch {
+	case !enabled:
+		c.TLS = nil
+	case c.TLS == nil:
+		c.TLS = &TLSConfig{}
+	}
+}
+
+// parseDuration parses a non negative duration into target
+func parseDuration(value string, target *time.Duration) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return fmt.Errorf("can't be negative")
+	}
+	*target = duration
+	return nil
+}
+
+// LoadConfig builds a configuration from, in increasing order of precedence,
+// the defaults, the YAML or JSON file at path (skipped if path is empty), the
+// GHOTI_* environment variables and the given options
+func LoadConfig(path string, opts ...Option) (*Config, error) {
+	cfg := defaultConfig()
+
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+	cfg.resolveTLS()
+
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigFile builds a configuration from the defaults and the YAML or JSON
+// file at path. The format is chosen by the file extension.
+func LoadConfigFile(path string) (*Config, error) {
+	cfg := defaultConfig()
+
+	if err := loadFile(cfg, path); err != nil {
+		return nil, err
+	}
+	cfg.resolveTLS()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigFromEnv builds a configuration from the defaults and the GHOTI_*
+// environment variables
+func LoadConfigFromEnv() (*Config, error) {
+	cfg := defaultConfig()
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, err
+	}
+	cfg.resolveTLS()
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadEnv applies the settings found in the environment
+func loadEnv(cfg *Config) error {
+	for _, s := range settings {
+		name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.key, ".", "_"))
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := s.apply(cfg, value); err != nil {
+			return fmt.Errorf("%w: %s %s: %w", ErrInvalidConfig, name, s.shown(value), err)
+		}
+	}
+
+	return nil
+}
+
+// loadFile applies the settings found in a YAML or JSON file
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	default:
+		return fmt.Errorf("%w: %s: unsupported config file format", ErrInvalidConfig, path)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s: failed to parse config file: %w", ErrInvalidConfig, path, err)
+	}
+
+	flat := make(map[string]string)
+	if err := flatten("", values, flat); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+
+	for _, s := range settings {
+		value, ok := flat[s.key]
+		if !ok {
+			continue
+		}
+		delete(flat, s.key)
+
+		if err := s.apply(cfg, value); err != nil {
+			return fmt.Errorf("%w: %s: invalid %s %s: %w", ErrInvalidConfig, path, s.key, s.shown(value), err)
+		}
+	}
+
+	// Anything left is not a known setting
+	unknown := make([]string, 0, len(flat))
+	for key := range flat {
+		unknown = append(unknown, key)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s: unknown keys: %s", ErrInvalidConfig, path, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// flatten turns nested values into dotted keys with string values
+func flatten(prefix string, values map[string]interface{}, flat map[string]string) error {
+	for key, value := range values {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, v, flat); err != nil {
+				return err
+			}
+		case string, bool, int, float64, json.Number:
+			flat[key] = fmt.Sprint(v)
+		case nil:
+			flat[key] = ""
+		default:
+			return fmt.Errorf("invalid %s: unsupported value %v", key, v)
+		}
+	}
+
+	return nil
+}
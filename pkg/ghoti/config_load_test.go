@@ -0,0 +1,154 @@
+package ghoti
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a config file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFileYAML(t *testing.T) {
+	path := writeFile(t, "ghoti.yaml", `
+server: ghoti:9090
+read_buffer_size: 1024
+timeout: 2s
+auth:
+  user: service
+  pass: secret
+reconnect:
+  max_attempts: 3
+  pending: retry
+`)
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tcp", cfg.Protocol())
+	assert.Equal(t, "ghoti:9090", cfg.Server())
+	assert.Equal(t, 1024, cfg.ReadBufferSize())
+	assert.Equal(t, 2*time.Second, cfg.Timeout())
+	assert.Equal(t, "service", cfg.Auth().User())
+	assert.Equal(t, "secret", cfg.Auth().Pass())
+	assert.Equal(t, 3, cfg.Reconnect.MaxAttempts)
+	assert.Equal(t, RetryPending, cfg.Reconnect.Pending)
+}
+
+func TestLoadConfigFileJSON(t *testing.T) {
+	path := writeFile(t, "ghoti.json", `{"server": "ghoti:9090", "read_buffer_size": 2048, "auth": {"user": "service"}}`)
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ghoti:9090", cfg.Server())
+	assert.Equal(t, 2048, cfg.ReadBufferSize())
+	assert.Equal(t, "service", cfg.Auth().User())
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := map[string]struct {
+		name    string
+		content string
+		message string
+	}{
+		"invalid value":  {"ghoti.yaml", "server: a:1\ntimeout: soon\n", "invalid timeout"},
+		"unknown key":    {"ghoti.yaml", "server: a:1\nauth:\n  token: x\n", "unknown keys: auth.token"},
+		"invalid format": {"ghoti.toml", "server = 1", "unsupported config file format"},
+		"invalid json":   {"ghoti.json", "{", "failed to parse config file"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadConfigFile(writeFile(t, test.name, test.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("GHOTI_SERVER", "ghoti:9090")
+	t.Setenv("GHOTI_TIMEOUT", "3s")
+	t.Setenv("GHOTI_AUTH_USER", "service")
+	t.Setenv("GHOTI_AUTH_PASS", "secret")
+
+	cfg, err := LoadConfigFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "ghoti:9090", cfg.Server())
+	assert.Equal(t, 3*time.Second, cfg.Timeout())
+	assert.Equal(t, "service", cfg.Auth().User())
+
+	t.Setenv("GHOTI_READ_BUFFER_SIZE", "big")
+	_, err = LoadConfigFromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "GHOTI_READ_BUFFER_SIZE")
+}
+
+func TestLoadConfigCredentials(t *testing.T) {
+	t.Setenv("GHOTI_SERVER", "ghoti:9090")
+	t.Setenv("GHOTI_AUTH_USER", "service")
+	t.Setenv("GHOTI_AUTH_PASS", "sec\nret")
+
+	// The error names the variable but not the password
+	_, err := LoadConfigFromEnv()
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "GHOTI_AUTH_PASS")
+	assert.NotContains(t, err.Error(), "sec")
+
+	_, err = LoadConfigFile(writeFile(t, "ghoti.yaml", "server: a:1\nauth:\n  user: \"ser\\nvice\"\n"))
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "invalid auth.user")
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeFile(t, "ghoti.yaml", "server: file:9090\ntimeout: 1s\nread_buffer_size: 1024\n")
+	t.Setenv("GHOTI_TIMEOUT", "2s")
+	t.Setenv("GHOTI_READ_BUFFER_SIZE", "2048")
+
+	cfg, err := LoadConfig(path, WithReadBufferSize(4096))
+	require.NoError(t, err)
+
+	assert.Equal(t, "file:9090", cfg.Server())
+	assert.Equal(t, 2*time.Second, cfg.Timeout())
+	assert.Equal(t, 4096, cfg.ReadBufferSize())
+}
+
+func TestLoadConfigTLS(t *testing.T) {
+	t.Setenv("GHOTI_SERVER", "ghoti:9090")
+
+	// Settings that don't say where to connect leave TLS disabled
+	t.Setenv("GHOTI_TLS_INSECURE_SKIP_VERIFY", "false")
+	t.Setenv("GHOTI_TLS_MIN_VERSION", "1.3")
+	cfg, err := LoadConfigFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.TLS)
+
+	t.Setenv("GHOTI_TLS_ENABLED", "true")
+	cfg, err = LoadConfigFromEnv()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.TLS)
+	assert.Equal(t, uint16(tls.VersionTLS13), cfg.TLS.MinVersion)
+
+	// A server name enables it, unless it is disabled explicitly
+	path := writeFile(t, "ghoti.yaml", "server: ghoti:9090\ntls:\n  server_name: ghoti.internal\n")
+	cfg, err = LoadConfigFile(path)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.TLS)
+	assert.Equal(t, "ghoti.internal", cfg.TLS.ServerName)
+
+	t.Setenv("GHOTI_TLS_ENABLED", "false")
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Nil(t, cfg.TLS)
+}
@@ -0,0 +1,82 @@
+package ghoti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigDefaults(t *testing.T) {
+	cfg, err := NewConfig("localhost:9090")
+	require.NoError(t, err)
+
+	assert.Equal(t, "tcp", cfg.Protocol())
+	assert.Equal(t, "localhost:9090", cfg.Server())
+	assert.Equal(t, DefaultReadBufferSize, cfg.ReadBufferSize())
+	assert.Equal(t, DefaultTimeout, cfg.Timeout())
+	assert.Nil(t, cfg.Auth())
+	assert.False(t, cfg.AutoAuth())
+}
+
+func TestNewConfigOptions(t *testing.T) {
+	cfg, err := NewConfig("localhost:9090",
+		WithAuth("user", "pass"),
+		WithTimeout(time.Second),
+		WithReadBufferSize(1024),
+		WithNetwork("tcp4"),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "tcp4", cfg.Protocol())
+	assert.Equal(t, 1024, cfg.ReadBufferSize())
+	assert.Equal(t, time.Second, cfg.Timeout())
+	assert.Equal(t, "user", cfg.Auth().User())
+	assert.Equal(t, "pass", cfg.Auth().Pass())
+	assert.True(t, cfg.AutoAuth())
+}
+
+func TestNewConfigValidation(t *testing.T) {
+	tests := map[string][]Option{
+		"timeout":     {WithTimeout(0)},
+		"buffer size": {WithReadBufferSize(-1)},
+		"network":     {WithNetwork("udp")},
+		"credentials": {WithAuth("", "pass")},
+		"line break":  {WithAuth("user\n", "pass")},
+		"jitter":      {WithReconnectPolicy(ReconnectPolicy{Jitter: 2})},
+		"codec slot":  {WithValueCodec(1000, protocol.Hex)},
+		"nil codec":   {WithValueCodec(1, nil)},
+	}
+
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewConfig("localhost:9090", opts...)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewConfig("")
+	assert.Error(t, err)
+
+	// A rejected codec is not kept in the configuration
+	cfg := &Config{}
+	assert.ErrorIs(t, WithValueCodec(1, nil)(cfg), ErrInvalidConfig)
+	assert.Nil(t, cfg.SlotValueCodecs)
+}
+
+func TestNew(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := New(listener.Addr().String(), WithAuth("test_user", "test_pass"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.Read(3)
+	require.NoError(t, err)
+	assert.Equal(t, "003", value)
+
+	_, err = New(listener.Addr().String(), WithAuth("test_user", "wrong"))
+	assert.Error(t, err)
+}
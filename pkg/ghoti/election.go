@@ -0,0 +1,266 @@
+package ghoti
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// Transition is a change of leadership seen by a candidate
+type Transition struct {
+	// Leader is the id of the new leader, empty when there is none
+	Leader string
+	// Elected tells if the candidate is the new leader
+	Elected bool
+}
+
+// ElectionOption configures an Election
+type ElectionOption func(*Election)
+
+// WithAnnouncements sends the id of the leader to the broadcast slot every
+// time the candidate is elected, and an empty message when it steps down. The
+// observers of the candidate receive the announcements of the others.
+func WithAnnouncements(slot int) ElectionOption {
+	return func(e *Election) {
+		e.announce = e.client.BroadcastSlot(slot)
+	}
+}
+
+// WithMutexOptions configures the mutex used to hold the leadership, for
+// example its lease
+func WithMutexOptions(opts ...MutexOption) ElectionOption {
+	return func(e *Election) {
+		e.mutexOpts = append(e.mutexOpts, opts...)
+	}
+}
+
+// Election elects a single leader among the candidates campaigning for a
+// timeout memory slot. The leader holds a Mutex whose token is its id, and
+// steps down if it can't renew the lease, for example because the connection
+// was lost, before the server lets another candidate in.
+type Election struct {
+	client    *Client
+	slot      int
+	id        string
+	announce  *BroadcastSlot
+	mutexOpts []MutexOption
+	mutex     *Mutex
+
+	// campaign serializes the campaigns, lock guards the state and
+	// announcing keeps the announcements in the order of the transitions
+	campaign   sync.Mutex
+	lock       sync.Mutex
+	announcing sync.Mutex
+	elected    bool
+	stop       chan struct{}
+	observers  []chan Transition
+	// unsubscribe stops following the announcements once Observe started it
+	unsubscribe Unsubscribe
+	closed      bool
+}
+
+// Election creates a candidate with the given id for the timeout memory slot
+// with the given number. The id must be unique among the candidates and at
+// most 36 characters long.
+func (c *Client) Election(slot int, id string, opts ...ElectionOption) *Election {
+	e := &Election{client: c, slot: slot, id: id}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.mutex = c.Mutex(slot, append(e.mutexOpts, WithToken(id))...)
+
+	return e
+}
+
+// ID returns the id of the candidate
+func (e *Election) ID() string {
+	return e.id
+}
+
+// Campaign waits until the candidate is elected or the context is done
+func (e *Election) Campaign(ctx context.Context) error {
+	e.campaign.Lock()
+	defer e.campaign.Unlock()
+
+	if e.IsLeader() {
+		return nil
+	}
+
+	if err := e.mutex.Lock(ctx); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	e.elected = true
+	e.stop = make(chan struct{})
+	go e.watch(e.mutex.Lost(), e.stop)
+
+	e.client.logger.Info("elected leader", slog.Int("slot", e.slot), slog.String("id", e.id))
+	e.notify(Transition{Leader: e.id, Elected: true})
+
+	e.announcing.Lock()
+	e.lock.Unlock()
+	e.announceLeader(ctx, e.id)
+
+	return nil
+}
+
+// Resign steps down if the candidate is the leader
+func (e *Election) Resign() error {
+	ctx, cancel := e.client.timeoutContext()
+	defer cancel()
+	return e.ResignContext(ctx)
+}
+
+// ResignContext steps down if the candidate is the leader, sending the
+// commands until the context is done
+func (e *Election) ResignContext(ctx context.Context) error {
+	e.lock.Lock()
+
+	if !e.elected {
+		e.lock.Unlock()
+		return nil
+	}
+
+	close(e.stop)
+	e.elected = false
+	e.notify(Transition{})
+
+	e.announcing.Lock()
+	e.lock.Unlock()
+
+	err := e.mutex.UnlockContext(ctx)
+	if errors.Is(err, ErrNotLocked) {
+		err = nil
+	}
+
+	e.client.logger.Info("resigned leadership", slog.Int("slot", e.slot), slog.String("id", e.id))
+	e.announceLeader(ctx, "")
+
+	return err
+}
+
+// Close resigns if the candidate is the leader and stops receiving the
+// announcements of the other candidates
+func (e *Election) Close() error {
+	err := e.Resign()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.closed = true
+	if e.unsubscribe != nil {
+		e.unsubscribe()
+		e.unsubscribe = nil
+	}
+
+	return err
+}
+
+// IsLeader tells if the candidate is the leader
+func (e *Election) IsLeader() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.elected
+}
+
+// Leader returns the id of the current leader, or an empty string if there is none
+func (e *Election) Leader() (string, error) {
+	return e.client.Read(e.slot)
+}
+
+// LeaderContext returns the id of the current leader until the context is done
+func (e *Election) LeaderContext(ctx context.Context) (string, error) {
+	return e.client.ReadContext(ctx, e.slot)
+}
+
+// Observe returns a channel receiving the leadership transitions of the
+// candidate. With WithAnnouncements it also receives the leaders announced by
+// the other candidates, so candidates that are not elected learn who the
+// leader is. Transitions are dropped if the channel is full.
+func (e *Election) Observe() <-chan Transition {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.announce != nil && e.unsubscribe == nil && !e.closed {
+		var messages <-chan Message
+		messages, e.unsubscribe = e.announce.Subscribe()
+		go e.follow(messages)
+	}
+
+	ch := make(chan Transition, 8)
+	e.observers = append(e.observers, ch)
+	return ch
+}
+
+// follow notifies the leaders announced by the other candidates until the
+// election or the client is closed. The server doesn't send the candidate its own
+// announcements.
+func (e *Election) follow(messages <-chan Message) {
+	for message := range messages {
+		e.lock.Lock()
+		e.notify(Transition{Leader: message.Data})
+		e.lock.Unlock()
+	}
+}
+
+// watch steps down when the lease of the leader is lost
+func (e *Election) watch(lost <-chan struct{}, stop <-chan struct{}) {
+	select {
+	case <-stop:
+		return
+	case <-lost:
+	}
+
+	e.lock.Lock()
+
+	// Resign may have run while waiting for the lock
+	select {
+	case <-stop:
+		e.lock.Unlock()
+		return
+	default:
+	}
+
+	// The mutex already forgot the lost lease
+	close(e.stop)
+	e.elected = false
+	e.notify(Transition{})
+
+	e.announcing.Lock()
+	e.lock.Unlock()
+
+	e.client.logger.Warn("lost leadership", slog.Int("slot", e.slot), slog.String("id", e.id))
+
+	ctx, cancel := e.client.timeoutContext()
+	defer cancel()
+	e.announceLeader(ctx, "")
+}
+
+// announceLeader sends the leader to the announcements slot, if there is one.
+// It must be called with announcing held, and releases it once sent.
+func (e *Election) announceLeader(ctx context.Context, leader string) {
+	defer e.announcing.Unlock()
+
+	if e.announce == nil {
+		return
+	}
+
+	if _, _, _, err := e.announce.SendContext(ctx, leader); err != nil {
+		e.client.logger.Warn("failed to announce leader", slog.Int("slot", e.announce.slot), slog.String("error", err.Error()))
+	}
+}
+
+// notify sends a transition to the observers without blocking. It must be
+// called with the lock held.
+func (e *Election) notify(transition Transition) {
+	for _, ch := range e.observers {
+		select {
+		case ch <- transition:
+		default:
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ghoti
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive waits for a value from a channel
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+
+	select {
+	case value := <-ch:
+		return value
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for value")
+		panic("unreachable")
+	}
+}
+
+func TestElection(t *testing.T) {
+	server := newMutexServer(t, 3, time.Minute)
+	server.SetSlot(2, ghotitest.SlotConfig{Kind: ghotitest.Broadcast})
+
+	announcements := make(chan string, 4)
+	observer := newMutexClient(t, server, 2)
+	observer.SetBroadcastHandler(func(slot int, data string) {
+		announcements <- data
+	})
+
+	first := newMutexClient(t, server, 0).Election(1, "worker-a", WithAnnouncements(2))
+	second := newMutexClient(t, server, 1).Election(1, "worker-b",
+		WithAnnouncements(2),
+		WithMutexOptions(WithRetryInterval(5*time.Millisecond)),
+	)
+	transitions := first.Observe()
+	followed := second.Observe()
+
+	require.NoError(t, first.Campaign(context.Background()))
+	assert.True(t, first.IsLeader())
+	assert.Equal(t, Transition{Leader: "worker-a", Elected: true}, receive(t, transitions))
+	assert.Equal(t, "worker-a", receive(t, announcements))
+
+	// The other candidates learn the leader from the announcements
+	assert.Equal(t, Transition{Leader: "worker-a"}, receive(t, followed))
+
+	leader, err := second.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "worker-a", leader)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, second.Campaign(ctx), ErrTimeout)
+	assert.False(t, second.IsLeader())
+
+	elected := make(chan error, 1)
+	go func() {
+		elected <- second.Campaign(context.Background())
+	}()
+
+	require.NoError(t, first.Resign())
+	assert.False(t, first.IsLeader())
+	assert.Equal(t, Transition{}, receive(t, transitions))
+	assert.Equal(t, "", receive(t, announcements))
+	assert.Equal(t, Transition{}, receive(t, followed))
+
+	require.NoError(t, receive(t, elected))
+	assert.Equal(t, "worker-b", receive(t, announcements))
+	assert.Equal(t, Transition{Leader: "worker-b", Elected: true}, receive(t, followed))
+	assert.Equal(t, Transition{Leader: "worker-b"}, receive(t, transitions))
+
+	leader, err = first.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "worker-b", leader)
+	require.NoError(t, second.Resign())
+}
+
+func TestElectionStepsDownOnConnectionLoss(t *testing.T) {
+	const lease = 150 * time.Millisecond
+
+	server := newMutexServer(t, 2, lease)
+
+	client, err := New(server.Addr(),
+		WithAuth("user0", "secret"),
+		WithReconnectPolicy(ReconnectPolicy{Disabled: true}),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	first := client.Election(1, "worker-a", WithMutexOptions(WithLease(lease)))
+	second := newMutexClient(t, server, 1).Election(1, "worker-b",
+		WithMutexOptions(WithLease(lease), WithRetryInterval(10*time.Millisecond)),
+	)
+	transitions := first.Observe()
+
+	require.NoError(t, first.Campaign(context.Background()))
+	assert.True(t, receive(t, transitions).Elected)
+
+	server.DropConnections()
+
+	// The leader steps down since it can't renew the lease
+	assert.Equal(t, Transition{}, receive(t, transitions))
+	assert.False(t, first.IsLeader())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, second.Campaign(ctx))
+	assert.True(t, second.IsLeader())
+	require.NoError(t, second.Resign())
+}
+
+func TestElectionSlowRenewal(t *testing.T) {
+	const lease = 300 * time.Millisecond
+
+	server := newMutexServer(t, 1, lease)
+	election := newMutexClient(t, server, 0).Election(1, "worker-a", WithMutexOptions(WithLease(lease)))
+	transitions := election.Observe()
+
+	require.NoError(t, election.Campaign(context.Background()))
+	assert.True(t, receive(t, transitions).Elected)
+
+	// A renewal answered after half of the lease keeps the leadership
+	server.Pause()
+	time.Sleep(lease/2 + lease/6)
+	server.Resume()
+
+	select {
+	case transition := <-transitions:
+		t.Fatalf("unexpected transition %+v", transition)
+	case <-time.After(lease):
+	}
+	assert.True(t, election.IsLeader())
+	require.NoError(t, election.Resign())
+}
+
+func TestElectionAnnouncesLostLeadership(t *testing.T) {
+	const lease = 60 * time.Millisecond
+
+	server := newMutexServer(t, 2, time.Minute)
+	server.SetSlot(2, ghotitest.SlotConfig{Kind: ghotitest.Broadcast})
+
+	client := newMutexClient(t, server, 0)
+	first := client.Election(1, "worker-a", WithAnnouncements(2), WithMutexOptions(WithLease(lease)))
+	second := newMutexClient(t, server, 1).Election(1, "worker-b", WithAnnouncements(2))
+	followed := second.Observe()
+
+	require.NoError(t, first.Campaign(context.Background()))
+	assert.Equal(t, Transition{Leader: "worker-a"}, receive(t, followed))
+
+	// Someone else with the same user overwrites the id of the leader
+	require.NoError(t, client.Write(1, "other"))
+	assert.Equal(t, Transition{}, receive(t, followed))
+	assert.False(t, first.IsLeader())
+
+	// Closing stops following the announcements
+	require.NoError(t, second.Close())
+	second.client.mutex.Lock()
+	assert.Empty(t, second.client.subscribers[2])
+	second.client.mutex.Unlock()
+}
@@ -0,0 +1,30 @@
+package ghoti
+
+import "github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+
+// Sentinel errors matched with errors.Is, see the model package for details
+var (
+	ErrInvalidCommand     = model.ErrInvalidCommand
+	ErrInvalidSlot        = model.ErrInvalidSlot
+	ErrDataTooLong        = model.ErrDataTooLong
+	ErrAuthRequired       = model.ErrAuthRequired
+	ErrInvalidCredentials = model.ErrInvalidCredentials
+	ErrPermissionDenied   = model.ErrPermissionDenied
+	ErrSlotLocked         = model.ErrSlotLocked
+	ErrNoTokens           = model.ErrNoTokens
+	ErrServer             = model.ErrServer
+	ErrTimeout            = model.ErrTimeout
+	ErrCanceled           = model.ErrCanceled
+	ErrClosed             = model.ErrClosed
+	ErrConnectionLost     = model.ErrConnectionLost
+	ErrReconnecting       = model.ErrReconnecting
+	ErrInvalidResponse    = model.ErrInvalidResponse
+	ErrInvalidConfig      = model.ErrInvalidConfig
+	ErrInvalidValue       = model.ErrInvalidValue
+)
+
+// Retryable tells if an operation that failed with err may succeed if it is
+// tried again later
+func Retryable(err error) bool {
+	return model.Retryable(err)
+}
@@ -0,0 +1,63 @@
+package ghoti
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientErrors(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 0}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Users: map[string]ghotitest.Permission{"service": ghotitest.Read}}),
+	)
+	defer server.Close()
+
+	client, err := New(server.Addr(), WithAuth("service", "secret"))
+	require.NoError(t, err)
+
+	_, err = client.Read(1000)
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+
+	err = client.Write(0, strings.Repeat("x", 37))
+	assert.ErrorIs(t, err, ErrDataTooLong)
+
+	_, err = client.Read(1)
+	assert.ErrorIs(t, err, ErrNoTokens)
+	assert.True(t, Retryable(err))
+
+	err = client.Write(2, "value")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.False(t, Retryable(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ReadContext(ctx, 0)
+	assert.ErrorIs(t, err, ErrCanceled)
+
+	require.NoError(t, client.Close())
+	_, err = client.Read(0)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestClientTimeoutError(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.ReadContext(ctx, 999)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, Retryable(err))
+}
@@ -0,0 +1,240 @@
+package ghoti
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultWaitInterval is the time a Limiter waits before trying again after
+// a request is denied
+const DefaultWaitInterval = 50 * time.Millisecond
+
+// Limiter limits the rate of events with a bucket kept in the server, so the
+// limit is shared by every client using the same slot. It follows the
+// semantics of golang.org/x/time/rate: a denied request is not an error.
+type Limiter interface {
+	// Allow tells if an event may happen now, taking a token if it does
+	Allow() bool
+	// Wait blocks until an event may happen or the context is done
+	Wait(ctx context.Context) error
+	// Reserve tries to take a token and tells how long to wait before trying
+	// again if there was none. It only fails on errors that waiting won't
+	// fix, like a missing permission on the slot.
+	Reserve(ctx context.Context) (Reservation, error)
+}
+
+// Reservation is the result of Limiter.Reserve
+type Reservation struct {
+	// OK tells if a token was taken and the event may happen
+	OK bool
+	// Remaining is the number of tokens left in a token bucket after the
+	// reservation, or -1 when it is not known
+	Remaining int
+	// Delay is the time to wait before trying again when OK is false
+	Delay time.Duration
+	// Fallback tells if the decision was taken locally because the server
+	// was unreachable
+	Fallback bool
+}
+
+// Fallback decides if an event may happen while the server is unreachable
+type Fallback interface {
+	Allow() bool
+}
+
+// FallbackFunc is a function that implements Fallback
+type FallbackFunc func() bool
+
+// Allow calls the function
+func (f FallbackFunc) Allow() bool {
+	return f()
+}
+
+var (
+	// FailOpen allows every event while the server is unreachable
+	FailOpen Fallback = FallbackFunc(func() bool { return true })
+	// FailClosed denies every event while the server is unreachable
+	FailClosed Fallback = FallbackFunc(func() bool { return false })
+)
+
+// LocalFallback limits the events of this process with a local token bucket
+// while the server is unreachable. The bucket holds up to burst tokens and
+// gets one back every interval.
+func LocalFallback(interval time.Duration, burst int) Fallback {
+	return &localBucket{interval: interval, burst: burst, tokens: burst, updated: time.Now()}
+}
+
+// localBucket is a token bucket kept in memory
+type localBucket struct {
+	interval time.Duration
+	burst    int
+
+	mutex   sync.Mutex
+	tokens  int
+	updated time.Time
+}
+
+// Allow takes a token if there is one
+func (b *localBucket) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.interval > 0 && b.tokens < b.burst {
+		earned := int(time.Since(b.updated) / b.interval)
+		b.tokens = min(b.burst, b.tokens+earned)
+		b.updated = b.updated.Add(time.Duration(earned) * b.interval)
+	}
+
+	if b.tokens == 0 {
+		return false
+	}
+
+	// A full bucket starts earning tokens once one is taken
+	if b.tokens == b.burst {
+		b.updated = time.Now()
+	}
+	b.tokens--
+	return true
+}
+
+// LimiterOption configures a Limiter
+type LimiterOption func(*limiter)
+
+// WithFallback sets how events are decided while the server is unreachable,
+// FailClosed by default
+func WithFallback(fallback Fallback) LimiterOption {
+	return func(l *limiter) {
+		l.fallback = fallback
+	}
+}
+
+// WithWaitInterval sets the time to wait before trying again after a request
+// is denied, usually the refill rate of the bucket
+func WithWaitInterval(interval time.Duration) LimiterOption {
+	return func(l *limiter) {
+		l.interval = interval
+	}
+}
+
+// limiter implements Limiter with a function that takes a token from a slot
+type limiter struct {
+	client   backend
+	slot     int
+	acquire  func(ctx context.Context) (bool, int, error)
+	fallback Fallback
+	interval time.Duration
+}
+
+// Limiter returns a Limiter that takes a token from the bucket for every event
+func (s *TokenBucketSlot) Limiter(opts ...LimiterOption) Limiter {
+	return newLimiter(s.client, s.slot, func(ctx context.Context) (bool, int, error) {
+		tokens, err := s.GetTokensContext(ctx)
+		if errors.Is(err, ErrNoTokens) {
+			return false, 0, nil
+		}
+		return err == nil, tokens, err
+	}, opts)
+}
+
+// Limiter returns a Limiter that adds a request to the bucket for every event
+func (s *LeakyBucketSlot) Limiter(opts ...LimiterOption) Limiter {
+	return newLimiter(s.client, s.slot, func(ctx context.Context) (bool, int, error) {
+		ok, err := s.TryAcquireContext(ctx)
+		return ok, -1, err
+	}, opts)
+}
+
+// newLimiter creates a limiter with the options applied
+func newLimiter(client backend, slot int, acquire func(ctx context.Context) (bool, int, error), opts []LimiterOption) *limiter {
+	l := &limiter{
+		client:   client,
+		slot:     slot,
+		acquire:  acquire,
+		fallback: FailClosed,
+		interval: DefaultWaitInterval,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Allow tells if an event may happen now, taking a token if it does. Reserve
+// bounds the request to the server, so a server that doesn't answer leads to
+// the fallback.
+func (l *limiter) Allow() bool {
+	reservation, err := l.Reserve(context.Background())
+	return err == nil && reservation.OK
+}
+
+// Wait blocks until an event may happen or the context is done
+func (l *limiter) Wait(ctx context.Context) error {
+	for {
+		reservation, err := l.Reserve(ctx)
+		if err != nil {
+			return err
+		}
+		if reservation.OK {
+			return nil
+		}
+
+		select {
+		case <-time.After(reservation.Delay):
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	}
+}
+
+// Reserve tries to take a token and tells how long to wait before trying
+// again if there was none
+func (l *limiter) Reserve(ctx context.Context) (Reservation, error) {
+	// A server that doesn't answer in time is unreachable, even if the
+	// connection is still up
+	acquireCtx, cancel := context.WithTimeout(ctx, l.client.requestTimeout())
+	defer cancel()
+
+	ok, remaining, err := l.acquire(acquireCtx)
+	if err == nil {
+		return l.reservation(ok, remaining, false), nil
+	}
+
+	// The caller gave up, there is nothing to fall back to
+	if ctx.Err() != nil {
+		return Reservation{}, err
+	}
+
+	if !unreachable(err) {
+		return Reservation{}, err
+	}
+
+	l.client.log().Debug("server unreachable, using rate limiter fallback",
+		slog.Int("slot", l.slot),
+		slog.String("error", err.Error()),
+	)
+
+	return l.reservation(l.fallback.Allow(), -1, true), nil
+}
+
+// reservation creates the reservation for a decision
+func (l *limiter) reservation(ok bool, remaining int, fallback bool) Reservation {
+	reservation := Reservation{OK: ok, Remaining: remaining, Fallback: fallback}
+	if !ok {
+		reservation.Delay = l.interval
+	}
+	return reservation
+}
+
+// unreachable tells if an error means the server couldn't be reached, as
+// opposed to an error returned by the server
+func unreachable(err error) bool {
+	return errors.Is(err, ErrTimeout) ||
+		errors.Is(err, ErrConnectionLost) ||
+		errors.Is(err, ErrReconnecting) ||
+		errors.Is(err, ErrClosed)
+}
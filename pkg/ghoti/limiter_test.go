@@ -0,0 +1,122 @@
+package ghoti
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 2, Rate: time.Minute}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.LeakyBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(3, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 1, Users: map[string]ghotitest.Permission{"service": ghotitest.Read}}),
+	)
+	defer server.Close()
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Run("token bucket", func(t *testing.T) {
+		limiter := client.TokenBucket(1).Limiter(WithWaitInterval(5 * time.Millisecond))
+
+		reservation, err := limiter.Reserve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Reservation{OK: true, Remaining: 1}, reservation)
+
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.Wait(ctx), ErrTimeout)
+
+		// The request cancelled by Wait is answered before this one, so the
+		// clock is not moved until the server is done with it
+		reservation, err = limiter.Reserve(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, Reservation{Delay: 5 * time.Millisecond}, reservation)
+
+		waited := make(chan error, 1)
+		go func() {
+			waited <- limiter.Wait(context.Background())
+		}()
+
+		server.Advance(time.Minute)
+		require.NoError(t, receive(t, waited))
+	})
+
+	t.Run("leaky bucket", func(t *testing.T) {
+		limiter := client.LeakyBucket(2).Limiter()
+
+		assert.True(t, limiter.Allow())
+		assert.False(t, limiter.Allow())
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		limiter := client.TokenBucket(3).Limiter(WithFallback(FailOpen))
+
+		_, err := limiter.Reserve(context.Background())
+		assert.ErrorIs(t, err, ErrAuthRequired)
+		assert.ErrorIs(t, limiter.Wait(context.Background()), ErrAuthRequired)
+		assert.False(t, limiter.Allow())
+	})
+}
+
+func TestLimiterFallback(t *testing.T) {
+	server := ghotitest.NewServer()
+	defer server.Close()
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+
+	bucket := client.TokenBucket(1)
+
+	assert.False(t, bucket.Limiter().Allow())
+	assert.True(t, bucket.Limiter(WithFallback(FailOpen)).Allow())
+
+	limiter := bucket.Limiter(WithFallback(LocalFallback(time.Minute, 2)))
+	assert.True(t, limiter.Allow())
+
+	reservation, err := limiter.Reserve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Reservation{OK: true, Remaining: -1, Fallback: true}, reservation)
+
+	assert.False(t, limiter.Allow())
+}
+
+func TestLimiterFallbackServerHangs(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 10, Rate: time.Minute}),
+	)
+	defer server.Close()
+
+	client, err := New(server.Addr(), WithTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The connection stays up but nothing is answered
+	server.Pause()
+	defer server.Resume()
+
+	bucket := client.TokenBucket(1)
+	assert.False(t, bucket.Limiter().Allow())
+	assert.True(t, bucket.Limiter(WithFallback(FailOpen)).Allow())
+
+	reservation, err := bucket.Limiter(WithFallback(LocalFallback(time.Minute, 1))).Reserve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Reservation{OK: true, Remaining: -1, Fallback: true}, reservation)
+
+	// A caller that gives up first gets its own error
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = bucket.Limiter(WithFallback(FailOpen)).Reserve(ctx)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
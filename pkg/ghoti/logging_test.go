@@ -0,0 +1,127 @@
+package ghoti
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logBuffer collects the JSON logs written by a client
+type logBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+// records returns the logged records
+func (b *logBuffer) records(t *testing.T) []map[string]interface{} {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buffer.String()), "\n") {
+		record := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// find returns the first record with the given message and attributes
+func find(records []map[string]interface{}, msg string, attrs map[string]interface{}) map[string]interface{} {
+	for _, record := range records {
+		if record["msg"] != msg {
+			continue
+		}
+
+		match := true
+		for key, value := range attrs {
+			if record[key] != value {
+				match = false
+			}
+		}
+		if match {
+			return record
+		}
+	}
+	return nil
+}
+
+func TestClientLogging(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket}),
+	)
+	defer server.Close()
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client, err := New(server.Addr(), WithAuth("service", "secret"), WithLogger(logger))
+	require.NoError(t, err)
+
+	require.NoError(t, client.Write(5, "hello"))
+	_, err = client.Read(1)
+	require.Error(t, err)
+	require.NoError(t, client.Close())
+
+	records := logs.records(t)
+
+	assert.NotNil(t, find(records, "connected", map[string]interface{}{"address": server.Addr()}))
+	assert.NotNil(t, find(records, "authenticated", map[string]interface{}{"user": "service"}))
+	assert.NotNil(t, find(records, "connection closed", nil))
+
+	write := find(records, "request completed", map[string]interface{}{"command": "write", "slot": float64(5)})
+	require.NotNil(t, write)
+	assert.Contains(t, write, "latency")
+	assert.NotContains(t, write, "error")
+
+	read := find(records, "request completed", map[string]interface{}{"command": "read", "slot": float64(1)})
+	require.NotNil(t, read)
+	assert.Equal(t, "008", read["code"])
+
+	// Neither the credentials nor the values are logged
+	assert.NotNil(t, find(records, "request completed", map[string]interface{}{"command": "password"}))
+	assert.NotContains(t, logs.buffer.String(), "secret")
+	assert.NotContains(t, logs.buffer.String(), "hello")
+}
+
+func TestClientLoggingAuthFailure(t *testing.T) {
+	server := ghotitest.NewServer(ghotitest.WithUser("service", "secret"))
+	defer server.Close()
+
+	logs := &logBuffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, nil))
+
+	_, err := New(server.Addr(), WithAuth("service", "wrong"), WithLogger(logger))
+	require.Error(t, err)
+
+	failed := find(logs.records(t), "authentication failed", map[string]interface{}{"user": "service"})
+	require.NotNil(t, failed)
+	assert.Equal(t, "005", failed["code"])
+	assert.NotContains(t, logs.buffer.String(), "wrong")
+}
+
+func TestConfigLogValue(t *testing.T) {
+	cfg, err := NewConfig("localhost:9090", WithAuth("service", "secret"))
+	require.NoError(t, err)
+
+	logs := &logBuffer{}
+	slog.New(slog.NewJSONHandler(logs, nil)).Info("config", slog.Any("config", cfg), slog.Any("auth", cfg.Auth()))
+
+	assert.Contains(t, logs.buffer.String(), `"user":"service"`)
+	assert.Contains(t, logs.buffer.String(), `"password":"[REDACTED]"`)
+	assert.NotContains(t, logs.buffer.String(), "secret")
+}
@@ -0,0 +1,359 @@
+package ghoti
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLease is the time a Mutex expects the server to keep the slot locked
+	DefaultLease = 10 * time.Second
+	// DefaultRetryInterval is the time Mutex.Lock waits between attempts
+	DefaultRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrNotLocked is returned when unlocking a Mutex that is not held, either
+	// because it was never locked or because its lease expired
+	ErrNotLocked = errors.New("mutex not locked")
+	// ErrLeaseLost is returned when unlocking a Mutex whose lease was lost
+	// while it was held. It wraps ErrNotLocked.
+	ErrLeaseLost = fmt.Errorf("%w: lease lost", ErrNotLocked)
+)
+
+// MutexOption configures a Mutex
+type MutexOption func(*Mutex)
+
+// WithLease sets the timeout of the slot configured in the server. The lease
+// is renewed every third of it while the mutex is held, so it must be at
+// least 3ns.
+func WithLease(lease time.Duration) MutexOption {
+	return func(m *Mutex) {
+		m.lease = lease
+	}
+}
+
+// WithToken sets the owner token written to the slot instead of a random
+// one. It must be unique among the holders and at most 36 characters long.
+func WithToken(token string) MutexOption {
+	return func(m *Mutex) {
+		m.token = token
+	}
+}
+
+// WithRetryInterval sets the time Lock waits between attempts
+func WithRetryInterval(interval time.Duration) MutexOption {
+	return func(m *Mutex) {
+		m.retry = interval
+	}
+}
+
+// Mutex is a distributed lock held in a timeout memory slot. The holder
+// writes a random owner token to the slot and renews it until Unlock, so the
+// lock is released by the server if the holder dies.
+//
+// The server keeps users other than the holder out of the slot until the
+// lease expires. Clients authenticated as the same user can overwrite each
+// other, so the token stops them from releasing or renewing a lock they don't
+// hold, but every process should use its own user for strict exclusion.
+type Mutex struct {
+	slot  *TimeoutMemorySlot
+	lease time.Duration
+	retry time.Duration
+	token string
+
+	// err is set if the options are not valid, it is returned when locking
+	err error
+
+	// mutex guards the state below, it is never held during a request
+	mutex     sync.Mutex
+	acquiring bool
+	held      string
+	expired   bool
+	stop      chan struct{}
+	done      chan struct{}
+	lost      chan struct{}
+}
+
+// NewMutex creates a mutex on a timeout memory slot. Invalid options make
+// Lock and TryLock fail with ErrInvalidConfig.
+func NewMutex(slot *TimeoutMemorySlot, opts ...MutexOption) *Mutex {
+	m := &Mutex{
+		slot:  slot,
+		lease: DefaultLease,
+		retry: DefaultRetryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.lease/3 <= 0 {
+		m.err = fmt.Errorf("%w: mutex lease must be at least 3ns: %s", ErrInvalidConfig, m.lease)
+	}
+
+	return m
+}
+
+// Mutex returns a mutex on the timeout memory slot with the given number
+func (c *Client) Mutex(slot int, opts ...MutexOption) *Mutex {
+	return NewMutex(c.TimeoutMemory(slot), opts...)
+}
+
+// Lock waits until the mutex is acquired or the context is done
+func (m *Mutex) Lock(ctx context.Context) error {
+	for {
+		acquired, err := m.TryLockContext(ctx)
+		if err != nil && !Retryable(err) {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-time.After(m.retry):
+		case <-ctx.Done():
+			return contextError(ctx.Err())
+		}
+	}
+}
+
+// TryLock tries to acquire the mutex without waiting for it to be released
+func (m *Mutex) TryLock() (bool, error) {
+	ctx, cancel := m.slot.client.timeoutContext()
+	defer cancel()
+	return m.TryLockContext(ctx)
+}
+
+// TryLockContext tries to acquire the mutex without waiting for it to be
+// released, sending the commands until the context is done
+func (m *Mutex) TryLockContext(ctx context.Context) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+
+	m.mutex.Lock()
+	if m.held != "" || m.acquiring {
+		m.mutex.Unlock()
+		return false, nil
+	}
+	m.acquiring = true
+	m.mutex.Unlock()
+
+	token, err := m.acquire(ctx)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.acquiring = false
+	if token == "" {
+		return false, err
+	}
+
+	m.held = token
+	m.expired = false
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	m.lost = make(chan struct{})
+	go m.renew(token, m.stop, m.done, m.lost)
+
+	return true, nil
+}
+
+// acquire writes a token to the slot if it is free, returning the token or
+// an empty string if the slot is taken
+func (m *Mutex) acquire(ctx context.Context) (string, error) {
+	value, err := m.slot.ReadContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if value != "" {
+		return "", nil
+	}
+
+	token := m.token
+	if token == "" {
+		if token, err = newToken(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := m.slot.WriteContext(ctx, token); err != nil {
+		if errors.Is(err, ErrSlotLocked) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// Another client of the same user may have written at the same time,
+	// the last write wins
+	value, err = m.slot.ReadContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if value != token {
+		return "", nil
+	}
+
+	return token, nil
+}
+
+// Unlock releases the mutex
+func (m *Mutex) Unlock() error {
+	ctx, cancel := m.slot.client.timeoutContext()
+	defer cancel()
+	return m.UnlockContext(ctx)
+}
+
+// UnlockContext releases the mutex, sending the commands until the context
+// is done. It returns ErrNotLocked if the mutex was not held, and
+// ErrLeaseLost if its lease was lost while it was held.
+func (m *Mutex) UnlockContext(ctx context.Context) error {
+	m.mutex.Lock()
+	if m.held == "" {
+		expired := m.expired
+		m.expired = false
+		m.mutex.Unlock()
+
+		if expired {
+			return ErrLeaseLost
+		}
+		return ErrNotLocked
+	}
+
+	token, stop, done := m.held, m.stop, m.done
+	m.held, m.lost = "", nil
+	m.mutex.Unlock()
+
+	// Stop renewing before releasing so the lease is not taken back
+	close(stop)
+	<-done
+
+	value, err := m.slot.ReadContext(ctx)
+	if err != nil {
+		return err
+	}
+	if value != token {
+		return ErrLeaseLost
+	}
+
+	return m.slot.WriteContext(ctx, "")
+}
+
+// Lost returns a channel closed when the mutex stops being held without
+// calling Unlock, because the lease expired or was taken by someone else. The
+// mutex can be locked again once it is closed. It returns nil if the mutex is
+// not held.
+func (m *Mutex) Lost() <-chan struct{} {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.held == "" {
+		return nil
+	}
+	return m.lost
+}
+
+// renew writes the token again every third of the lease until stop is
+// closed or the lease is lost. The lease is given up once half of it passed
+// without renewing, so the holder steps down before the server releases it.
+func (m *Mutex) renew(token string, stop <-chan struct{}, done, lost chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(m.lease / 3)
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		err := m.renewOnce(token)
+		if err == nil {
+			renewed = time.Now()
+			continue
+		}
+		if errors.Is(err, ErrNotLocked) || time.Since(renewed) > m.lease/2 {
+			m.giveUp(lost)
+			return
+		}
+	}
+}
+
+// giveUp forgets a lost lease and closes its channel, unless Unlock is
+// already releasing it
+func (m *Mutex) giveUp(lost chan struct{}) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.lost != lost {
+		return
+	}
+
+	m.held, m.lost = "", nil
+	m.expired = true
+	close(lost)
+}
+
+// renewOnce writes the token again if the slot still holds it
+func (m *Mutex) renewOnce(token string) error {
+	ctx, cancel := m.slot.client.timeoutContext()
+	defer cancel()
+
+	value, err := m.slot.ReadContext(ctx)
+	if err != nil {
+		return err
+	}
+	if value != token {
+		return ErrNotLocked
+	}
+
+	err = m.slot.WriteContext(ctx, token)
+	if errors.Is(err, ErrSlotLocked) {
+		return ErrNotLocked
+	}
+	return err
+}
+
+// Locker returns a sync.Locker for the mutex. Its Lock blocks until the
+// mutex is acquired, and both methods panic on errors that can't be retried,
+// like sync.Mutex panics when unlocking an unlocked mutex. Unlocking after
+// the lease was lost doesn't panic, the loss is reported by Lost.
+func (m *Mutex) Locker() sync.Locker {
+	return locker{m}
+}
+
+// locker adapts a Mutex to sync.Locker
+type locker struct {
+	m *Mutex
+}
+
+func (l locker) Lock() {
+	if err := l.m.Lock(context.Background()); err != nil {
+		panic(fmt.Sprintf("ghoti: failed to lock mutex: %v", err))
+	}
+}
+
+func (l locker) Unlock() {
+	if err := l.m.Unlock(); err != nil && !errors.Is(err, ErrLeaseLost) {
+		panic(fmt.Sprintf("ghoti: failed to unlock mutex: %v", err))
+	}
+}
+
+// newToken returns a random owner token
+func newToken() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to create owner token: %w", err)
+	}
+	return hex.EncodeToString(buffer), nil
+}
@@ -0,0 +1,270 @@
+package ghoti
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMutexServer starts a server with a timeout memory slot on slot 1 and the
+// given number of users, named user0, user1...
+func newMutexServer(t *testing.T, users int, timeout time.Duration) *ghotitest.Server {
+	opts := []ghotitest.Option{
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TimeoutMemory, Timeout: timeout}),
+	}
+	for i := 0; i < users; i++ {
+		opts = append(opts, ghotitest.WithUser(fmt.Sprintf("user%d", i), "secret"))
+	}
+
+	server := ghotitest.NewServer(opts...)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newMutexClient connects to the server as the given user
+func newMutexClient(t *testing.T, server *ghotitest.Server, user int) *Client {
+	client, err := New(server.Addr(), WithAuth(fmt.Sprintf("user%d", user), "secret"))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestMutexTryLock(t *testing.T) {
+	server := newMutexServer(t, 2, time.Minute)
+	first := newMutexClient(t, server, 0).Mutex(1, WithLease(time.Minute))
+	second := newMutexClient(t, server, 1).Mutex(1, WithLease(time.Minute))
+
+	acquired, err := first.TryLock()
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Held by this mutex or by another user
+	acquired, err = first.TryLock()
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	acquired, err = second.TryLock()
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, first.Unlock())
+	assert.Equal(t, "", server.Value(1))
+	assert.ErrorIs(t, first.Unlock(), ErrNotLocked)
+
+	acquired, err = second.TryLock()
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	require.NoError(t, second.Unlock())
+}
+
+func TestMutexLockWaits(t *testing.T) {
+	server := newMutexServer(t, 2, time.Minute)
+	first := newMutexClient(t, server, 0).Mutex(1, WithLease(time.Minute))
+	second := newMutexClient(t, server, 1).Mutex(1, WithLease(time.Minute), WithRetryInterval(5*time.Millisecond))
+
+	require.NoError(t, first.Lock(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, second.Lock(ctx), ErrTimeout)
+
+	locked := make(chan error, 1)
+	go func() {
+		locked <- second.Lock(context.Background())
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, first.Unlock())
+	require.NoError(t, <-locked)
+	require.NoError(t, second.Unlock())
+}
+
+func TestMutexConcurrent(t *testing.T) {
+	const workers = 4
+	const rounds = 5
+
+	server := newMutexServer(t, workers, time.Minute)
+
+	var holders, maxHolders, total int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		locker := newMutexClient(t, server, i).Mutex(1, WithRetryInterval(time.Millisecond)).Locker()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 0; round < rounds; round++ {
+				locker.Lock()
+
+				current := atomic.AddInt32(&holders, 1)
+				for {
+					previous := atomic.LoadInt32(&maxHolders)
+					if current <= previous || atomic.CompareAndSwapInt32(&maxHolders, previous, current) {
+						break
+					}
+				}
+				atomic.AddInt32(&total, 1)
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+
+				locker.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxHolders)
+	assert.Equal(t, int32(workers*rounds), total)
+}
+
+func TestMutexExpiry(t *testing.T) {
+	server := newMutexServer(t, 2, time.Minute)
+	first := newMutexClient(t, server, 0).Mutex(1, WithLease(time.Minute))
+	second := newMutexClient(t, server, 1).Mutex(1, WithLease(time.Minute))
+
+	require.NoError(t, first.Lock(context.Background()))
+
+	// The holder didn't renew in time, so the server released the slot
+	server.Advance(2 * time.Minute)
+
+	acquired, err := second.TryLock()
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The old holder can't release the new lock
+	assert.ErrorIs(t, first.Unlock(), ErrNotLocked)
+	assert.NotEqual(t, "", server.Value(1))
+	require.NoError(t, second.Unlock())
+}
+
+func TestMutexRenewal(t *testing.T) {
+	const lease = 150 * time.Millisecond
+
+	server := newMutexServer(t, 2, lease)
+	first := newMutexClient(t, server, 0).Mutex(1, WithLease(lease))
+	second := newMutexClient(t, server, 1).Mutex(1, WithLease(lease))
+
+	require.NoError(t, first.Lock(context.Background()))
+
+	// The lease is renewed while the mutex is held
+	deadline := time.Now().Add(3 * lease)
+	for time.Now().Before(deadline) {
+		acquired, err := second.TryLock()
+		require.NoError(t, err)
+		require.False(t, acquired)
+		time.Sleep(lease / 5)
+	}
+
+	select {
+	case <-first.Lost():
+		t.Fatal("lease lost while renewing")
+	default:
+	}
+
+	require.NoError(t, first.Unlock())
+}
+
+func TestMutexLost(t *testing.T) {
+	const lease = 60 * time.Millisecond
+
+	server := newMutexServer(t, 2, time.Minute)
+	client := newMutexClient(t, server, 0)
+	mutex := client.Mutex(1, WithLease(lease))
+
+	require.NoError(t, mutex.Lock(context.Background()))
+	lost := mutex.Lost()
+
+	// Someone else with the same user overwrites the token
+	require.NoError(t, client.Write(1, "other"))
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("lost lease not detected")
+	}
+
+	assert.Nil(t, mutex.Lost())
+	assert.ErrorIs(t, mutex.Unlock(), ErrLeaseLost)
+	assert.ErrorIs(t, mutex.Unlock(), ErrNotLocked)
+
+	// The mutex can be locked again once the slot is free
+	require.NoError(t, client.Write(1, ""))
+	acquired, err := mutex.TryLock()
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The locker doesn't panic when the lease was lost
+	require.NoError(t, client.Write(1, "other"))
+	require.Eventually(t, func() bool { return mutex.Lost() == nil }, time.Second, time.Millisecond)
+	assert.NotPanics(t, mutex.Locker().Unlock)
+}
+
+func TestMutexNotLockedDuringRequests(t *testing.T) {
+	server := newMutexServer(t, 1, time.Minute)
+	mutex := newMutexClient(t, server, 0).Mutex(1)
+
+	server.Pause()
+	defer server.Resume()
+
+	acquired := make(chan bool, 1)
+	go func() {
+		ok, _ := mutex.TryLock()
+		acquired <- ok
+	}()
+
+	// The state can be checked while the server doesn't answer
+	require.Eventually(t, func() bool {
+		ok, err := mutex.TryLock()
+		return err == nil && !ok
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, mutex.Lost())
+	assert.ErrorIs(t, mutex.Unlock(), ErrNotLocked)
+
+	server.Resume()
+	assert.True(t, receive(t, acquired))
+	require.NoError(t, mutex.Unlock())
+}
+
+func TestMutexSlowRenewal(t *testing.T) {
+	const lease = 300 * time.Millisecond
+
+	server := newMutexServer(t, 1, lease)
+	mutex := newMutexClient(t, server, 0).Mutex(1, WithLease(lease))
+
+	require.NoError(t, mutex.Lock(context.Background()))
+	lost := mutex.Lost()
+
+	// The first renewal is answered after half of the lease, but in time
+	server.Pause()
+	time.Sleep(lease/2 + lease/6)
+	server.Resume()
+
+	select {
+	case <-lost:
+		t.Fatal("lease lost after a successful renewal")
+	case <-time.After(lease):
+	}
+
+	require.NoError(t, mutex.Unlock())
+}
+
+func TestMutexInvalidLease(t *testing.T) {
+	server := newMutexServer(t, 1, time.Minute)
+	client := newMutexClient(t, server, 0)
+
+	for _, lease := range []time.Duration{0, 2, -time.Second} {
+		mutex := client.Mutex(1, WithLease(lease))
+
+		_, err := mutex.TryLock()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorIs(t, mutex.Lock(context.Background()), ErrInvalidConfig)
+	}
+	assert.Equal(t, "", server.Value(1))
+}
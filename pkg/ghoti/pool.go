@@ -0,0 +1,642 @@
+package ghoti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/internal/config"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+const (
+	// DefaultPoolMinConns is the number of connections a Pool keeps open
+	DefaultPoolMinConns = 1
+	// DefaultPoolMaxConns is the number of connections a Pool opens at most
+	DefaultPoolMaxConns = 8
+	// DefaultHealthCheckInterval is the time between health checks of a Pool
+	DefaultHealthCheckInterval = 30 * time.Second
+	// DefaultIdleTimeout is the time a Pool keeps an unused connection open
+	// when it has more than the minimum
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// HealthCheck tells if a connection of a Pool is usable, the connections that
+// fail it are closed and replaced
+type HealthCheck func(ctx context.Context, client *Client) error
+
+// PoolOption configures a Pool
+type PoolOption func(*Pool)
+
+// WithMinConns sets the number of connections the pool keeps open
+func WithMinConns(n int) PoolOption {
+	return func(p *Pool) {
+		p.min = n
+	}
+}
+
+// WithMaxConns sets the number of connections the pool opens at most
+func WithMaxConns(n int) PoolOption {
+	return func(p *Pool) {
+		p.max = n
+	}
+}
+
+// WithIdleTimeout sets the time an unused connection is kept open when the
+// pool has more than the minimum, 0 keeps them open
+func WithIdleTimeout(timeout time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.idleTimeout = timeout
+	}
+}
+
+// WithHealthCheck sets the time between health checks, 0 disables them, and
+// the check run on the idle connections. By default the check only fails for
+// connections that are not connected.
+func WithHealthCheck(interval time.Duration, check HealthCheck) PoolOption {
+	return func(p *Pool) {
+		p.checkInterval = interval
+		if check != nil {
+			p.check = check
+		}
+	}
+}
+
+// connectedCheck is the default health check
+func connectedCheck(_ context.Context, client *Client) error {
+	if state := client.State(); state != StateConnected {
+		return fmt.Errorf("%w: connection %s", ErrConnectionLost, state)
+	}
+	return nil
+}
+
+// poolConn is a connection of a Pool
+type poolConn struct {
+	client   *Client
+	inFlight int
+	lastUsed time.Time
+}
+
+// PoolStats describes the connections of a Pool
+type PoolStats struct {
+	// Conns is the number of connections sending requests, the connection
+	// receiving broadcasts is not included
+	Conns int
+	// InUse is the number of connections with requests in flight
+	InUse int
+}
+
+// Pool sends requests over several connections to the server, opening them
+// as the load grows. It has the same API as a Client. Broadcasts are received
+// by a dedicated connection, so subscriptions get every message once no
+// matter how many connections the pool has.
+type Pool struct {
+	config        config.Config
+	min           int
+	max           int
+	idleTimeout   time.Duration
+	checkInterval time.Duration
+	check         HealthCheck
+
+	// subscriptions is the connection receiving the broadcasts
+	subscriptions *Client
+
+	mutex   sync.Mutex
+	conns   []*poolConn
+	dialing int
+	closed  bool
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a Pool from a configuration, opening the minimum number of
+// connections. Every connection authenticates if the configuration asks for it.
+func NewPool(config config.Config, opts ...PoolOption) (*Pool, error) {
+	p := &Pool{
+		config:        config,
+		min:           DefaultPoolMinConns,
+		max:           DefaultPoolMaxConns,
+		idleTimeout:   DefaultIdleTimeout,
+		checkInterval: DefaultHealthCheckInterval,
+		check:         connectedCheck,
+		done:          make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.min < 0 || p.max < 1 || p.min > p.max {
+		return nil, fmt.Errorf("%w: pool: connections must be between 0 and a maximum of at least 1", ErrInvalidConfig)
+	}
+
+	if p.idleTimeout < 0 || p.checkInterval < 0 {
+		return nil, fmt.Errorf("%w: pool: intervals can't be negative", ErrInvalidConfig)
+	}
+
+	subscriptions, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	p.subscriptions = subscriptions
+
+	for len(p.conns) < p.min {
+		client, err := NewClient(config)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.conns = append(p.conns, &poolConn{client: client, lastUsed: time.Now()})
+	}
+
+	if p.checkInterval > 0 {
+		p.wg.Add(1)
+		go p.maintain()
+	}
+
+	return p, nil
+}
+
+// Close closes every connection of the pool
+func (p *Pool) Close() error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	conns := p.conns
+	p.conns = nil
+	p.mutex.Unlock()
+
+	p.wg.Wait()
+
+	errs := []error{p.subscriptions.Close()}
+	for _, conn := range conns {
+		errs = append(errs, conn.client.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Stats returns the number of connections of the pool
+func (p *Pool) Stats() PoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := PoolStats{Conns: len(p.conns)}
+	for _, conn := range p.conns {
+		if conn.inFlight > 0 {
+			stats.InUse++
+		}
+	}
+	return stats
+}
+
+// acquire returns the connection to send a request over. An idle connection
+// is preferred. When all of them are busy the least busy one is shared while
+// a new one is opened in the background, so requests don't wait for a dial.
+func (p *Pool) acquire() (*poolConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil, ErrClosed
+	}
+
+	conn := p.leastBusy()
+	if conn != nil && conn.inFlight > 0 && len(p.conns)+p.dialing < p.max {
+		p.dialing++
+		p.wg.Add(1)
+		go p.grow()
+	}
+
+	// Without connections there is nothing to share, so wait for the dial
+	if conn == nil && len(p.conns)+p.dialing < p.max {
+		p.dialing++
+		p.mutex.Unlock()
+		var err error
+		conn, err = p.open()
+		p.mutex.Lock()
+
+		if errors.Is(err, ErrClosed) {
+			return nil, err
+		}
+		// Another request may have opened a connection meanwhile
+		if err != nil {
+			if conn = p.leastBusy(); conn == nil {
+				return nil, err
+			}
+		}
+	}
+
+	if conn == nil {
+		return nil, fmt.Errorf("%w: no pool connection available", ErrConnectionLost)
+	}
+
+	conn.inFlight++
+	return conn, nil
+}
+
+// grow opens a connection for the busy pool in the background
+func (p *Pool) grow() {
+	defer p.wg.Done()
+
+	if _, err := p.open(); err != nil && !errors.Is(err, ErrClosed) {
+		p.log().Warn("failed to open pool connection", slog.String("error", err.Error()))
+	}
+}
+
+// open dials a connection and adds it to the pool. It must be called without
+// the mutex held, after counting the dial in dialing.
+func (p *Pool) open() (*poolConn, error) {
+	client, err := NewClient(p.config)
+
+	p.mutex.Lock()
+	p.dialing--
+	closed := p.closed
+	var conn *poolConn
+	if err == nil && !closed {
+		conn = &poolConn{client: client, lastUsed: time.Now()}
+		p.conns = append(p.conns, conn)
+	}
+	p.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if closed {
+		client.Close()
+		return nil, ErrClosed
+	}
+
+	return conn, nil
+}
+
+// leastBusy removes the closed connections and returns the connected one
+// with the fewest requests in flight, or a reconnecting one if none is
+// connected. It must be called with the mutex held.
+func (p *Pool) leastBusy() *poolConn {
+	var best *poolConn
+	bestConnected := false
+
+	conns := p.conns[:0]
+	for _, conn := range p.conns {
+		state := conn.client.State()
+		if state == StateClosed {
+			continue
+		}
+		conns = append(conns, conn)
+
+		connected := state == StateConnected
+		if best == nil || (connected && !bestConnected) ||
+			(connected == bestConnected && conn.inFlight < best.inFlight) {
+			best, bestConnected = conn, connected
+		}
+	}
+	p.conns = conns
+
+	return best
+}
+
+// release returns a connection after its request is done
+func (p *Pool) release(conn *poolConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	conn.inFlight--
+	conn.lastUsed = time.Now()
+}
+
+// do runs fn with the least busy connection of the pool
+func (p *Pool) do(fn func(client *Client) error) error {
+	conn, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	defer p.release(conn)
+
+	return fn(conn.client)
+}
+
+// maintain runs the health checks until the pool is closed
+func (p *Pool) maintain() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.evictIdle()
+			p.checkHealth()
+			p.fill()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// evictIdle closes the connections unused for longer than the idle timeout,
+// as long as the pool keeps the minimum
+func (p *Pool) evictIdle() {
+	if p.idleTimeout == 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	var evicted []*poolConn
+	conns := p.conns[:0]
+	for _, conn := range p.conns {
+		if len(p.conns)-len(evicted) > p.min && conn.inFlight == 0 && time.Since(conn.lastUsed) >= p.idleTimeout {
+			evicted = append(evicted, conn)
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	p.conns = conns
+	p.mutex.Unlock()
+
+	for _, conn := range evicted {
+		conn.client.Close()
+	}
+	if len(evicted) > 0 {
+		p.log().Debug("closed idle pool connections", slog.Int("count", len(evicted)))
+	}
+}
+
+// checkHealth runs the health check on the idle connections, closing the
+// ones that fail it
+func (p *Pool) checkHealth() {
+	p.mutex.Lock()
+	var idle []*poolConn
+	for _, conn := range p.conns {
+		if conn.inFlight == 0 {
+			idle = append(idle, conn)
+		}
+	}
+	p.mutex.Unlock()
+
+	for _, conn := range idle {
+		ctx, cancel := p.timeoutContext()
+		err := p.check(ctx, conn.client)
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		p.log().Warn("pool connection failed health check", slog.String("error", err.Error()))
+		p.remove(conn)
+		conn.client.Close()
+	}
+}
+
+// remove takes a connection out of the pool
+func (p *Pool) remove(conn *poolConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, other := range p.conns {
+		if other == conn {
+			p.conns = append(p.conns[:i:i], p.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// fill opens connections until the pool has the minimum
+func (p *Pool) fill() {
+	for {
+		p.mutex.Lock()
+		if p.closed || len(p.conns)+p.dialing >= p.min {
+			p.mutex.Unlock()
+			return
+		}
+		p.dialing++
+		p.mutex.Unlock()
+
+		if _, err := p.open(); err != nil {
+			if !errors.Is(err, ErrClosed) {
+				p.log().Warn("failed to open pool connection", slog.String("error", err.Error()))
+			}
+			return
+		}
+	}
+}
+
+// timeoutContext returns the context used by the methods that don't take one
+func (p *Pool) timeoutContext() (context.Context, context.CancelFunc) {
+	return p.subscriptions.timeoutContext()
+}
+
+// requestTimeout returns the time to wait for a response when no context is given
+func (p *Pool) requestTimeout() time.Duration {
+	return p.subscriptions.requestTimeout()
+}
+
+// log returns the logger of the pool
+func (p *Pool) log() *slog.Logger {
+	return p.subscriptions.log()
+}
+
+// Read reads the value from a slot
+func (p *Pool) Read(slot int) (string, error) {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.ReadContext(ctx, slot)
+}
+
+// ReadContext reads the value from a slot, waiting for the response until the
+// context is done
+func (p *Pool) ReadContext(ctx context.Context, slot int) (string, error) {
+	var value string
+	err := p.do(func(client *Client) (err error) {
+		value, err = client.ReadContext(ctx, slot)
+		return err
+	})
+	return value, err
+}
+
+// Write writes a value to a slot
+func (p *Pool) Write(slot int, data string) error {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.WriteContext(ctx, slot, data)
+}
+
+// WriteContext writes a value to a slot, waiting for the response until the
+// context is done
+func (p *Pool) WriteContext(ctx context.Context, slot int, data string) error {
+	_, err := p.write(ctx, slot, data)
+	return err
+}
+
+// write sends a write command and returns the data of the response
+func (p *Pool) write(ctx context.Context, slot int, data string) (string, error) {
+	var response string
+	err := p.do(func(client *Client) (err error) {
+		response, err = client.write(ctx, slot, data)
+		return err
+	})
+	return response, err
+}
+
+// Broadcast sends a message to all connected clients
+func (p *Pool) Broadcast(slot int, data string) (int, int, int, error) {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.BroadcastContext(ctx, slot, data)
+}
+
+// BroadcastContext sends a message to all connected clients, waiting for the
+// response until the context is done
+func (p *Pool) BroadcastContext(ctx context.Context, slot int, data string) (int, int, int, error) {
+	var received, total, failed int
+	err := p.do(func(client *Client) (err error) {
+		received, total, failed, err = client.BroadcastContext(ctx, slot, data)
+		return err
+	})
+	return received, total, failed, err
+}
+
+// WriteBytes writes a value to a slot, encoded with the codec the
+// configuration sets for the slot
+func (p *Pool) WriteBytes(slot int, value []byte) error {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.WriteBytesContext(ctx, slot, value)
+}
+
+// WriteBytesContext writes a value to a slot, encoded with the codec the
+// configuration sets for the slot, waiting for the response until the
+// context is done
+func (p *Pool) WriteBytesContext(ctx context.Context, slot int, value []byte) error {
+	return p.do(func(client *Client) error {
+		return client.WriteBytesContext(ctx, slot, value)
+	})
+}
+
+// ReadBytes reads the value of a slot, decoded with the codec the
+// configuration sets for the slot
+func (p *Pool) ReadBytes(slot int) ([]byte, error) {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.ReadBytesContext(ctx, slot)
+}
+
+// ReadBytesContext reads the value of a slot, decoded with the codec the
+// configuration sets for the slot, waiting for the response until the
+// context is done
+func (p *Pool) ReadBytesContext(ctx context.Context, slot int) ([]byte, error) {
+	var value []byte
+	err := p.do(func(client *Client) (err error) {
+		value, err = client.ReadBytesContext(ctx, slot)
+		return err
+	})
+	return value, err
+}
+
+// Batch returns an empty batch, its operations are sent over a single
+// connection of the pool
+func (p *Pool) Batch() *Batch {
+	return &Batch{client: p}
+}
+
+// ReadMany reads several slots in a single round trip
+func (p *Pool) ReadMany(slots ...int) ([]Result, error) {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.ReadManyContext(ctx, slots...)
+}
+
+// ReadManyContext reads several slots in a single round trip until the
+// context is done, the results are in the order of the slots
+func (p *Pool) ReadManyContext(ctx context.Context, slots ...int) ([]Result, error) {
+	return readMany(ctx, p.Batch(), slots)
+}
+
+// WriteMany writes several slots in a single round trip
+func (p *Pool) WriteMany(values map[int]string) ([]Result, error) {
+	ctx, cancel := p.timeoutContext()
+	defer cancel()
+	return p.WriteManyContext(ctx, values)
+}
+
+// WriteManyContext writes several slots in a single round trip until the
+// context is done, the results are sorted by slot
+func (p *Pool) WriteManyContext(ctx context.Context, values map[int]string) ([]Result, error) {
+	return writeMany(ctx, p.Batch(), values)
+}
+
+// sendMany sends several commands over a single connection
+func (p *Pool) sendMany(ctx context.Context, cmds []protocol.Command) ([]Response, error) {
+	var responses []Response
+	err := p.do(func(client *Client) (err error) {
+		responses, err = client.sendMany(ctx, cmds)
+		return err
+	})
+	return responses, err
+}
+
+// Subscribe returns a channel receiving the broadcasts sent to a slot, over
+// the connection of the pool dedicated to broadcasts
+func (p *Pool) Subscribe(slot int, opts ...SubscribeOption) (<-chan Message, Unsubscribe) {
+	return p.subscriptions.Subscribe(slot, opts...)
+}
+
+// Dropped returns the number of broadcasts of a slot dropped by the active
+// subscriptions because their buffer was full
+func (p *Pool) Dropped(slot int) uint64 {
+	return p.subscriptions.Dropped(slot)
+}
+
+// SetBroadcastHandler sets the handler of the broadcasts without subscribers
+func (p *Pool) SetBroadcastHandler(handler BroadcastHandler) {
+	p.subscriptions.SetBroadcastHandler(handler)
+}
+
+// SimpleMemory returns the simple memory slot with the given number
+func (p *Pool) SimpleMemory(slot int) *SimpleMemorySlot {
+	return &SimpleMemorySlot{client: p, slot: slot}
+}
+
+// TimeoutMemory returns the timeout memory slot with the given number
+func (p *Pool) TimeoutMemory(slot int) *TimeoutMemorySlot {
+	return &TimeoutMemorySlot{client: p, slot: slot}
+}
+
+// TokenBucket returns the token bucket slot with the given number
+func (p *Pool) TokenBucket(slot int) *TokenBucketSlot {
+	return &TokenBucketSlot{client: p, slot: slot}
+}
+
+// LeakyBucket returns the leaky bucket slot with the given number
+func (p *Pool) LeakyBucket(slot int) *LeakyBucketSlot {
+	return &LeakyBucketSlot{client: p, slot: slot}
+}
+
+// BroadcastSlot returns the broadcast slot with the given number
+func (p *Pool) BroadcastSlot(slot int) *BroadcastSlot {
+	return &BroadcastSlot{client: p, slot: slot}
+}
+
+// Ticker returns the ticker slot with the given number
+func (p *Pool) Ticker(slot int) *TickerSlot {
+	return &TickerSlot{client: p, slot: slot}
+}
+
+// AtomicCounter returns the atomic counter slot with the given number
+func (p *Pool) AtomicCounter(slot int) *AtomicCounterSlot {
+	return &AtomicCounterSlot{client: p, slot: slot}
+}
+
+// GetSlot returns a typed slot based on the slot type, for slot types only
+// known at runtime
+func (p *Pool) GetSlot(slotType SlotType, slot int) (interface{}, error) {
+	return getSlot(p, slotType, slot)
+}
@@ -0,0 +1,233 @@
+package ghoti
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPool starts a server and returns a pool connected to it
+func newPool(t *testing.T, opts ...PoolOption) (*ghotitest.Server, *Pool) {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.Broadcast}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter}),
+		ghotitest.WithSlot(3, ghotitest.SlotConfig{Kind: ghotitest.SimpleMemory, Users: map[string]ghotitest.Permission{"service": ghotitest.ReadWrite}}),
+	)
+	t.Cleanup(server.Close)
+
+	cfg, err := NewConfig(server.Addr(), WithAuth("service", "secret"))
+	require.NoError(t, err)
+
+	pool, err := NewPool(cfg, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+
+	return server, pool
+}
+
+func TestPool(t *testing.T) {
+	server, pool := newPool(t, WithMinConns(2), WithMaxConns(4))
+
+	// The minimum plus the connection receiving broadcasts
+	assert.Equal(t, 3, server.Connections())
+	assert.Equal(t, PoolStats{Conns: 2}, pool.Stats())
+
+	// Every connection is authenticated
+	require.NoError(t, pool.Write(3, "value"))
+	value, err := pool.Read(3)
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.AtomicCounter(2).Add(1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	counter, err := pool.AtomicCounter(2).Read()
+	require.NoError(t, err)
+	assert.Equal(t, 50, counter)
+	assert.LessOrEqual(t, pool.Stats().Conns, 4)
+	assert.Zero(t, pool.Stats().InUse)
+
+	require.NoError(t, pool.Close())
+	_, err = pool.Read(3)
+	assert.True(t, errors.Is(err, ErrClosed))
+	assert.Eventually(t, func() bool { return server.Connections() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestPoolGrows(t *testing.T) {
+	_, pool := newPool(t, WithMinConns(1), WithMaxConns(2))
+
+	// A busy connection is shared while a new one is opened
+	first, err := pool.acquire()
+	require.NoError(t, err)
+	second, err := pool.acquire()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	require.Eventually(t, func() bool { return pool.Stats().Conns == 2 }, time.Second, time.Millisecond)
+
+	third, err := pool.acquire()
+	require.NoError(t, err)
+	assert.NotSame(t, first, third, "the new connection is idle")
+
+	// At the maximum the least busy connection is shared
+	fourth, err := pool.acquire()
+	require.NoError(t, err)
+	assert.Same(t, third, fourth)
+	assert.Equal(t, PoolStats{Conns: 2, InUse: 2}, pool.Stats())
+
+	for _, conn := range []*poolConn{first, second, third, fourth} {
+		pool.release(conn)
+	}
+	assert.Equal(t, PoolStats{Conns: 2}, pool.Stats())
+}
+
+func TestPoolDoesNotWaitForDials(t *testing.T) {
+	server := ghotitest.NewServer()
+	t.Cleanup(server.Close)
+
+	// The connection for broadcasts and the minimum connect, then dials hang
+	var dials int32
+	unblock := make(chan struct{})
+	dialer := DialerFunc(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if atomic.AddInt32(&dials, 1) > 2 {
+			<-unblock
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	})
+
+	cfg, err := NewConfig(server.Addr(), WithDialer(dialer))
+	require.NoError(t, err)
+	pool, err := NewPool(cfg, WithMinConns(1), WithMaxConns(2))
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+	release := sync.OnceFunc(func() { close(unblock) })
+	t.Cleanup(release)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, pool.Write(1, "value"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, pool.Stats().Conns)
+
+	release()
+	assert.Eventually(t, func() bool { return pool.Stats().Conns == 2 }, time.Second, time.Millisecond)
+}
+
+func TestPoolIdleEviction(t *testing.T) {
+	_, pool := newPool(t,
+		WithMinConns(1),
+		WithMaxConns(3),
+		WithIdleTimeout(20*time.Millisecond),
+		WithHealthCheck(10*time.Millisecond, nil),
+	)
+
+	// Keep every connection busy until the pool reaches the maximum
+	var conns []*poolConn
+	require.Eventually(t, func() bool {
+		conn, err := pool.acquire()
+		require.NoError(t, err)
+		conns = append(conns, conn)
+		return pool.Stats().Conns == 3
+	}, time.Second, time.Millisecond)
+	for _, conn := range conns {
+		pool.release(conn)
+	}
+	assert.Equal(t, 3, pool.Stats().Conns)
+
+	assert.Eventually(t, func() bool { return pool.Stats().Conns == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	var mutex sync.Mutex
+	unhealthy := make(map[*Client]bool)
+
+	_, pool := newPool(t,
+		WithMinConns(2),
+		WithHealthCheck(10*time.Millisecond, func(ctx context.Context, client *Client) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if len(unhealthy) == 0 {
+				unhealthy[client] = true
+			}
+			if unhealthy[client] {
+				return errors.New("unhealthy")
+			}
+			return nil
+		}),
+	)
+
+	// The failing connection is closed and replaced
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for client := range unhealthy {
+			if client.State() != StateClosed {
+				return false
+			}
+		}
+		return len(unhealthy) == 1 && pool.Stats().Conns == 2
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := pool.Read(3)
+	assert.NoError(t, err)
+}
+
+func TestPoolSubscriptions(t *testing.T) {
+	server, pool := newPool(t, WithMinConns(3))
+
+	messages, unsubscribe := pool.BroadcastSlot(1).Subscribe()
+	defer unsubscribe()
+
+	sender, err := New(server.Addr())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	received, total, _, err := sender.Broadcast(1, "hello")
+	require.NoError(t, err)
+	assert.Equal(t, 4, total, "every connection of the pool gets the broadcast")
+	assert.Equal(t, 4, received)
+
+	// But the subscription gets it once
+	assert.Equal(t, Message{Slot: 1, Data: "hello"}, receive(t, messages))
+	select {
+	case message := <-messages:
+		t.Fatalf("duplicated broadcast: %v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNewPoolInvalidOptions(t *testing.T) {
+	cfg, err := NewConfig("127.0.0.1:1")
+	require.NoError(t, err)
+
+	for _, opts := range [][]PoolOption{
+		{WithMaxConns(0)},
+		{WithMinConns(3), WithMaxConns(2)},
+		{WithMinConns(-1)},
+		{WithIdleTimeout(-time.Second)},
+	} {
+		_, err := NewPool(cfg, opts...)
+		assert.True(t, errors.Is(err, ErrInvalidConfig))
+	}
+}
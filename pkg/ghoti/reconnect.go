@@ -0,0 +1,269 @@
+package ghoti
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/internal/logging"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// ConnectionState represents the state of the connection to the server
+type ConnectionState int
+
+const (
+	// StateConnected means the client has a working connection to the server
+	StateConnected ConnectionState = iota
+	// StateReconnecting means the connection was lost and the client is redialing
+	StateReconnecting
+	// StateClosed means the client was closed and will not reconnect
+	StateClosed
+)
+
+// String returns a human readable name for the state
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// StateHandler is a function that is notified of connection state changes
+type StateHandler func(state ConnectionState)
+
+// PendingPolicy decides what happens to requests in flight when the connection drops
+type PendingPolicy int
+
+const (
+	// FailPending fails in-flight requests as soon as the connection drops and
+	// rejects new requests until the client is connected again
+	FailPending PendingPolicy = iota
+	// RetryPending keeps in-flight and new requests waiting and sends them once
+	// the connection is restored, as long as they have not timed out
+	RetryPending
+)
+
+// ReconnectPolicy configures how the client reconnects after losing the connection
+type ReconnectPolicy struct {
+	// Disabled turns off reconnection, the client is closed on the first connection error
+	Disabled bool
+	// InitialBackoff is the delay before the first reconnection attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier is the factor applied to the delay after every failed attempt
+	Multiplier float64
+	// Jitter is the fraction (0 to 1) of the delay that is randomized
+	Jitter float64
+	// MaxAttempts is the number of attempts before giving up, 0 means forever
+	MaxAttempts int
+	// Pending decides what happens to requests in flight while reconnecting
+	Pending PendingPolicy
+}
+
+// DefaultReconnectPolicy returns the policy used by new clients
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Pending:        FailPending,
+	}
+}
+
+// validate checks the values of the policy
+func (p ReconnectPolicy) validate() error {
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("%w: reconnect policy: backoff can't be negative", ErrInvalidConfig)
+	}
+
+	if p.Multiplier < 0 {
+		return fmt.Errorf("%w: reconnect policy: multiplier can't be negative", ErrInvalidConfig)
+	}
+
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("%w: reconnect policy: jitter must be between 0 and 1", ErrInvalidConfig)
+	}
+
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("%w: reconnect policy: max attempts can't be negative", ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// backoff returns the delay to wait before the given attempt (starting at 0)
+func (p ReconnectPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt))
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1) * delay
+		delay = delay - jitter + rand.Float64()*2*jitter
+	}
+
+	// The cap goes last so the jitter never exceeds it
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	return time.Duration(delay)
+}
+
+// reconnect redials the server after a connection error. It returns false if
+// the client was closed or the policy gave up.
+func (c *Client) reconnect(cause error) bool {
+	c.mutex.Lock()
+	policy := c.reconnectPolicy
+	c.connected = false
+	c.conn.Close()
+	c.mutex.Unlock()
+
+	c.logger.Warn("connection lost", slog.String("error", cause.Error()))
+
+	if policy.Disabled {
+		return false
+	}
+
+	c.setState(StateReconnecting)
+
+	if policy.Pending == FailPending {
+		c.failPending(Response{Error: fmt.Errorf("%w: %w", ErrConnectionLost, cause)})
+	}
+
+	for attempt := 0; policy.MaxAttempts == 0 || attempt < policy.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-c.done:
+			return false
+		}
+
+		conn, err := c.dial()
+		if err != nil {
+			c.logger.Debug("reconnect attempt failed", slog.Int("attempt", attempt+1), slog.String("error", err.Error()))
+			continue
+		}
+
+		decoder := c.newDecoder(conn)
+
+		c.mutex.Lock()
+		authenticated := c.authenticated
+		c.mutex.Unlock()
+
+		if authenticated {
+			if err := c.handshake(conn, decoder); err != nil {
+				attrs := append([]slog.Attr{slog.Int("attempt", attempt+1)}, logging.Error(err)...)
+				c.logger.LogAttrs(context.Background(), slog.LevelWarn, "authentication failed while reconnecting", attrs...)
+				conn.Close()
+				continue
+			}
+		}
+
+		if !c.resume(conn, decoder) {
+			conn.Close()
+			return false
+		}
+
+		c.setState(StateConnected)
+		c.logger.Info("reconnected", slog.Int("attempts", attempt+1))
+		return true
+	}
+
+	c.logger.Error("giving up reconnecting", slog.Int("attempts", policy.MaxAttempts))
+	return false
+}
+
+// handshake sends the configured credentials over a new connection before it
+// is handed to the listener, reading the replies directly
+func (c *Client) handshake(conn net.Conn, decoder *protocol.Decoder) error {
+	// Don't let a silent server block the reconnection forever
+	conn.SetDeadline(time.Now().Add(c.requestTimeout()))
+	defer conn.SetDeadline(time.Time{})
+
+	encoder := protocol.NewEncoder(conn)
+	commands := []protocol.Command{
+		protocol.User(c.config.Auth().User()),
+		protocol.Pass(c.config.Auth().Pass()),
+	}
+
+	for _, cmd := range commands {
+		if err := encoder.EncodeCommand(cmd); err != nil {
+			return err
+		}
+
+		message, err := decoder.DecodeMessage()
+		if err != nil {
+			return err
+		}
+		if err := message.Err(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resume installs a new connection and resends the requests still waiting
+// for a response. It returns false if the client was closed meanwhile.
+func (c *Client) resume(conn net.Conn, decoder *protocol.Decoder) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	c.conn = conn
+	c.decoder = decoder
+	c.connected = true
+
+	// Resend the requests in their original order. Requests nobody waits
+	// for are dropped since their responses are not needed anymore.
+	pending := c.pending
+	c.pending = nil
+	for _, request := range pending {
+		if request.abandoned {
+			continue
+		}
+		// A failed write surfaces as a read error on the listener, which
+		// starts a new reconnection round
+		if err := c.enqueue(request); err != nil {
+			c.connected = false
+		}
+	}
+
+	return true
+}
+
+// setState updates the connection state and notifies the state handler
+func (c *Client) setState(state ConnectionState) {
+	c.mutex.Lock()
+	if c.state == state {
+		c.mutex.Unlock()
+		return
+	}
+	c.state = state
+	handler := c.stateHandler
+	c.mutex.Unlock()
+
+	if handler != nil {
+		handler(state)
+	}
+}
@@ -0,0 +1,144 @@
+package ghoti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	policy := ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(0))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, time.Second, policy.backoff(10))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := policy.backoff(1)
+		assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+		assert.LessOrEqual(t, delay, 300*time.Millisecond)
+
+		// The jitter doesn't go over the maximum
+		assert.LessOrEqual(t, policy.backoff(10), time.Second)
+	}
+}
+
+func TestClientReconnects(t *testing.T) {
+	listener, conns := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.SetReconnectPolicy(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, Multiplier: 2})
+
+	states := make(chan ConnectionState, 10)
+	client.SetStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+
+	value, err := client.Read(5)
+	require.NoError(t, err)
+	assert.Equal(t, "005", value)
+
+	// Drop the connection from the server side
+	(<-conns).Close()
+
+	assert.Equal(t, StateReconnecting, <-states)
+	assert.Equal(t, StateConnected, <-states)
+
+	value, err = client.Read(7)
+	require.NoError(t, err)
+	assert.Equal(t, "007", value)
+
+	client.Close()
+	assert.Equal(t, StateClosed, <-states)
+}
+
+func TestClientGivesUpReconnecting(t *testing.T) {
+	listener, conns := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.SetReconnectPolicy(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond, MaxAttempts: 2})
+
+	states := make(chan ConnectionState, 10)
+	client.SetStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+
+	// Stop accepting connections and drop the current one
+	listener.Close()
+	(<-conns).Close()
+
+	assert.Equal(t, StateReconnecting, <-states)
+	assert.Equal(t, StateClosed, <-states)
+
+	_, err = client.Read(5)
+	assert.Error(t, err)
+}
+
+func TestClientReconnectHandshakeTimeout(t *testing.T) {
+	server := ghotitest.NewServer(ghotitest.WithUser("service", "secret"))
+	defer server.Close()
+
+	client, err := New(server.Addr(),
+		WithAuth("service", "secret"),
+		WithTimeout(50*time.Millisecond),
+		WithReconnectPolicy(ReconnectPolicy{InitialBackoff: time.Millisecond, MaxAttempts: 1}),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	states := make(chan ConnectionState, 10)
+	client.SetStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+
+	// The server accepts the new connection but doesn't answer the
+	// credentials, the handshake gives up after the client timeout
+	server.Pause()
+	defer server.Resume()
+	server.DropConnections()
+
+	assert.Equal(t, StateReconnecting, receive(t, states))
+	assert.Equal(t, StateClosed, receive(t, states))
+}
+
+func TestClientReconnectsOnInvalidResponse(t *testing.T) {
+	listener, conns := echoServer(t)
+
+	client, err := NewClient(&testConfig{server: listener.Addr().String()})
+	require.NoError(t, err)
+	defer client.Close()
+
+	client.SetReconnectPolicy(ReconnectPolicy{InitialBackoff: 10 * time.Millisecond})
+
+	states := make(chan ConnectionState, 10)
+	client.SetStateHandler(func(state ConnectionState) {
+		states <- state
+	})
+
+	// The responses can't be matched to the requests after an invalid line,
+	// so the connection is replaced instead of closing the client
+	_, err = (<-conns).Write([]byte("x\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, StateReconnecting, receive(t, states))
+	assert.Equal(t, StateConnected, receive(t, states))
+
+	value, err := client.Read(7)
+	require.NoError(t, err)
+	assert.Equal(t, "007", value)
+}
@@ -0,0 +1,52 @@
+package ghoti
+
+import (
+	"context"
+	"sync"
+)
+
+// Sequence hands out unique IDs from an atomic counter. It reserves blocks
+// of IDs with a single increment and serves them from memory, so IDs are
+// increasing within a Sequence but interleave between processes, and the
+// unused IDs of a block are lost when the process stops.
+type Sequence struct {
+	counter *AtomicCounterSlot
+	block   int
+
+	mutex sync.Mutex
+	next  int
+	end   int
+}
+
+// Sequence returns a sequence reserving blocks of the given size from the
+// counter, a block of one reserves every ID on the server
+func (s *AtomicCounterSlot) Sequence(block int) *Sequence {
+	return &Sequence{counter: s, block: max(block, 1)}
+}
+
+// Next returns the next ID
+func (q *Sequence) Next() (int, error) {
+	ctx, cancel := q.counter.client.timeoutContext()
+	defer cancel()
+	return q.NextContext(ctx)
+}
+
+// NextContext returns the next ID, reserving a new block until the context
+// is done if the current one is used up
+func (q *Sequence) NextContext(ctx context.Context) (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.next == q.end {
+		// The counter holds the last ID of the reserved block
+		last, err := q.counter.AddContext(ctx, q.block)
+		if err != nil {
+			return 0, err
+		}
+		q.next, q.end = last-q.block+1, last+1
+	}
+
+	id := q.next
+	q.next++
+	return id, nil
+}
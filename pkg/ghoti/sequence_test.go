@@ -0,0 +1,82 @@
+package ghoti
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomicCounterAdd(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter, Value: "10"}),
+	)
+	defer server.Close()
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	counter := client.AtomicCounter(1)
+
+	value, err := counter.Add(5)
+	require.NoError(t, err)
+	assert.Equal(t, 15, value)
+
+	value, err = counter.Add(-20)
+	require.NoError(t, err)
+	assert.Equal(t, -5, value)
+}
+
+func TestSequence(t *testing.T) {
+	const clients = 3
+	const ids = 25
+
+	server := ghotitest.NewServer(
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter}),
+	)
+	defer server.Close()
+
+	var mutex sync.Mutex
+	seen := make(map[int]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		client, err := New(server.Addr())
+		require.NoError(t, err)
+		defer client.Close()
+
+		sequence := client.AtomicCounter(1).Sequence(10)
+
+		// Two goroutines share every sequence
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				previous := 0
+				for k := 0; k < ids; k++ {
+					id, err := sequence.Next()
+					if !assert.NoError(t, err) {
+						return
+					}
+					assert.Greater(t, id, previous)
+					previous = id
+
+					mutex.Lock()
+					assert.False(t, seen[id], "duplicated id %d", id)
+					seen[id] = true
+					mutex.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Len(t, seen, clients*2*ids)
+
+	// Every block of 10 was reserved with a single increment
+	assert.Equal(t, "150", server.Value(1))
+}
@@ -0,0 +1,132 @@
+package ghoti
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Codec converts the values of a Slot to and from the data stored in the
+// server, which can't be longer than 36 characters
+type Codec[T any] interface {
+	Encode(value T) (string, error)
+	Decode(data string) (T, error)
+}
+
+// StringCodec stores strings as they are
+var StringCodec Codec[string] = stringCodec{}
+
+// IntCodec stores integers in decimal
+var IntCodec Codec[int] = intCodec{}
+
+// BoolCodec stores booleans as "true" or "false"
+var BoolCodec Codec[bool] = boolCodec{}
+
+// JSONCodec stores values encoded as JSON, which is only useful for small
+// values given the size of the slots
+func JSONCodec[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(value string) (string, error) { return value, nil }
+func (stringCodec) Decode(data string) (string, error)  { return data, nil }
+
+type intCodec struct{}
+
+func (intCodec) Encode(value int) (string, error) {
+	return strconv.Itoa(value), nil
+}
+
+func (intCodec) Decode(data string) (int, error) {
+	return strconv.Atoi(data)
+}
+
+type boolCodec struct{}
+
+func (boolCodec) Encode(value bool) (string, error) {
+	return strconv.FormatBool(value), nil
+}
+
+func (boolCodec) Decode(data string) (bool, error) {
+	return strconv.ParseBool(data)
+}
+
+type jsonCodec[T any] struct{}
+
+func (jsonCodec[T]) Encode(value T) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+func (jsonCodec[T]) Decode(data string) (T, error) {
+	var value T
+	err := json.Unmarshal([]byte(data), &value)
+	return value, err
+}
+
+// Slot is a memory slot holding values of type T, converted with a codec. It
+// can be created on a Client or on a Pool.
+//
+//	retries := ghoti.NewSlot(client, 5, ghoti.IntCodec)
+//	err := retries.Write(3)
+//	n, err := retries.Read()
+type Slot[T any] struct {
+	client backend
+	slot   int
+	codec  Codec[T]
+}
+
+// NewSlot returns the slot with the given number of a Client or a Pool,
+// storing values with the codec
+func NewSlot[T any](client backend, slot int, codec Codec[T]) *Slot[T] {
+	return &Slot[T]{client: client, slot: slot, codec: codec}
+}
+
+// Number returns the number of the slot
+func (s *Slot[T]) Number() int {
+	return s.slot
+}
+
+// Read reads the value from the slot
+func (s *Slot[T]) Read() (T, error) {
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.ReadContext(ctx)
+}
+
+// ReadContext reads the value from the slot until the context is done
+func (s *Slot[T]) ReadContext(ctx context.Context) (T, error) {
+	var value T
+
+	data, err := s.client.ReadContext(ctx, s.slot)
+	if err != nil {
+		return value, err
+	}
+
+	value, err = s.codec.Decode(data)
+	if err != nil {
+		return value, fmt.Errorf("%w: failed to decode %q: %w", ErrInvalidResponse, data, err)
+	}
+
+	return value, nil
+}
+
+// Write writes a value to the slot
+func (s *Slot[T]) Write(value T) error {
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.WriteContext(ctx, value)
+}
+
+// WriteContext writes a value to the slot until the context is done
+func (s *Slot[T]) WriteContext(ctx context.Context, value T) error {
+	data, err := s.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	return s.client.WriteContext(ctx, s.slot, data)
+}
@@ -0,0 +1,76 @@
+package ghoti
+
+import (
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlot(t *testing.T) {
+	server := ghotitest.NewServer()
+	defer server.Close()
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Run("int", func(t *testing.T) {
+		slot := NewSlot(client, 1, IntCodec)
+		require.NoError(t, slot.Write(42))
+		assert.Equal(t, "42", server.Value(1))
+
+		value, err := slot.Read()
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		slot := NewSlot(client, 2, BoolCodec)
+		require.NoError(t, slot.Write(true))
+
+		value, err := slot.Read()
+		require.NoError(t, err)
+		assert.True(t, value)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		type point struct {
+			X int `json:"x"`
+			Y int `json:"y"`
+		}
+
+		slot := NewSlot(client, 3, JSONCodec[point]())
+		require.NoError(t, slot.Write(point{X: 1, Y: 2}))
+		assert.Equal(t, `{"x":1,"y":2}`, server.Value(3))
+
+		value, err := slot.Read()
+		require.NoError(t, err)
+		assert.Equal(t, point{X: 1, Y: 2}, value)
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		require.NoError(t, NewSlot(client, 4, StringCodec).Write("not a number"))
+
+		_, err := NewSlot(client, 4, IntCodec).Read()
+		assert.ErrorIs(t, err, ErrInvalidResponse)
+	})
+
+	t.Run("value too long", func(t *testing.T) {
+		err := NewSlot(client, 5, JSONCodec[[]int]()).Write(make([]int, 20))
+		assert.ErrorIs(t, err, ErrDataTooLong)
+	})
+}
+
+func TestSlotPool(t *testing.T) {
+	server, pool := newPool(t, WithMinConns(1))
+
+	slot := NewSlot(pool, 3, IntCodec)
+	require.NoError(t, slot.Write(7))
+	assert.Equal(t, "7", server.Value(3))
+
+	value, err := slot.Read()
+	require.NoError(t, err)
+	assert.Equal(t, 7, value)
+}
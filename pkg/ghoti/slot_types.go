@@ -1,8 +1,13 @@
 package ghoti
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
 )
 
 // SlotType represents the type of a slot
@@ -25,9 +30,26 @@ const (
 	AtomicCounter SlotType = "atomic_counter"
 )
 
+// backend sends the commands of the slot types, it is implemented by Client
+// and Pool
+type backend interface {
+	Read(slot int) (string, error)
+	ReadContext(ctx context.Context, slot int) (string, error)
+	Write(slot int, data string) error
+	WriteContext(ctx context.Context, slot int, data string) error
+	Broadcast(slot int, data string) (int, int, int, error)
+	BroadcastContext(ctx context.Context, slot int, data string) (int, int, int, error)
+	Subscribe(slot int, opts ...SubscribeOption) (<-chan Message, Unsubscribe)
+
+	write(ctx context.Context, slot int, data string) (string, error)
+	timeoutContext() (context.Context, context.CancelFunc)
+	requestTimeout() time.Duration
+	log() *slog.Logger
+}
+
 // SimpleMemorySlot provides methods for interacting with a simple memory slot
 type SimpleMemorySlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
@@ -36,14 +58,24 @@ func (s *SimpleMemorySlot) Read() (string, error) {
 	return s.client.Read(s.slot)
 }
 
+// ReadContext reads the value from the slot until the context is done
+func (s *SimpleMemorySlot) ReadContext(ctx context.Context) (string, error) {
+	return s.client.ReadContext(ctx, s.slot)
+}
+
 // Write writes a value to the slot
 func (s *SimpleMemorySlot) Write(data string) error {
 	return s.client.Write(s.slot, data)
 }
 
+// WriteContext writes a value to the slot until the context is done
+func (s *SimpleMemorySlot) WriteContext(ctx context.Context, data string) error {
+	return s.client.WriteContext(ctx, s.slot, data)
+}
+
 // TimeoutMemorySlot provides methods for interacting with a timeout memory slot
 type TimeoutMemorySlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
@@ -52,27 +84,44 @@ func (s *TimeoutMemorySlot) Read() (string, error) {
 	return s.client.Read(s.slot)
 }
 
+// ReadContext reads the value from the slot until the context is done
+func (s *TimeoutMemorySlot) ReadContext(ctx context.Context) (string, error) {
+	return s.client.ReadContext(ctx, s.slot)
+}
+
 // Write writes a value to the slot
 func (s *TimeoutMemorySlot) Write(data string) error {
 	return s.client.Write(s.slot, data)
 }
 
+// WriteContext writes a value to the slot until the context is done
+func (s *TimeoutMemorySlot) WriteContext(ctx context.Context, data string) error {
+	return s.client.WriteContext(ctx, s.slot, data)
+}
+
 // TokenBucketSlot provides methods for interacting with a token bucket slot
 type TokenBucketSlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
 // GetTokens gets tokens from the bucket
 func (s *TokenBucketSlot) GetTokens() (int, error) {
-	data, err := s.client.Read(s.slot)
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.GetTokensContext(ctx)
+}
+
+// GetTokensContext gets tokens from the bucket until the context is done
+func (s *TokenBucketSlot) GetTokensContext(ctx context.Context) (int, error) {
+	data, err := s.client.ReadContext(ctx, s.slot)
 	if err != nil {
 		return 0, err
 	}
 
 	tokens, err := strconv.Atoi(data)
 	if err != nil {
-		return 0, fmt.Errorf("invalid token count: %s", data)
+		return 0, fmt.Errorf("%w: invalid token count: %s", ErrInvalidResponse, data)
 	}
 
 	return tokens, nil
@@ -80,20 +129,27 @@ func (s *TokenBucketSlot) GetTokens() (int, error) {
 
 // LeakyBucketSlot provides methods for interacting with a leaky bucket slot
 type LeakyBucketSlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
 // TryAcquire tries to acquire a token from the bucket
 func (s *LeakyBucketSlot) TryAcquire() (bool, error) {
-	data, err := s.client.Read(s.slot)
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.TryAcquireContext(ctx)
+}
+
+// TryAcquireContext tries to acquire a token from the bucket until the context is done
+func (s *LeakyBucketSlot) TryAcquireContext(ctx context.Context) (bool, error) {
+	data, err := s.client.ReadContext(ctx, s.slot)
 	if err != nil {
 		return false, err
 	}
 
 	result, err := strconv.Atoi(data)
 	if err != nil {
-		return false, fmt.Errorf("invalid result: %s", data)
+		return false, fmt.Errorf("%w: invalid result: %s", ErrInvalidResponse, data)
 	}
 
 	return result == 1, nil
@@ -101,7 +157,7 @@ func (s *LeakyBucketSlot) TryAcquire() (bool, error) {
 
 // BroadcastSlot provides methods for interacting with a broadcast slot
 type BroadcastSlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
@@ -110,27 +166,44 @@ func (s *BroadcastSlot) Read() (string, error) {
 	return s.client.Read(s.slot)
 }
 
+// ReadContext reads the last value sent to the broadcast slot until the context is done
+func (s *BroadcastSlot) ReadContext(ctx context.Context) (string, error) {
+	return s.client.ReadContext(ctx, s.slot)
+}
+
 // Send sends a message to all connected clients
 func (s *BroadcastSlot) Send(data string) (int, int, int, error) {
 	return s.client.Broadcast(s.slot, data)
 }
 
+// SendContext sends a message to all connected clients until the context is done
+func (s *BroadcastSlot) SendContext(ctx context.Context, data string) (int, int, int, error) {
+	return s.client.BroadcastContext(ctx, s.slot, data)
+}
+
 // TickerSlot provides methods for interacting with a ticker slot
 type TickerSlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
 // Read reads the current value of the ticker
 func (s *TickerSlot) Read() (int, error) {
-	data, err := s.client.Read(s.slot)
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.ReadContext(ctx)
+}
+
+// ReadContext reads the current value of the ticker until the context is done
+func (s *TickerSlot) ReadContext(ctx context.Context) (int, error) {
+	data, err := s.client.ReadContext(ctx, s.slot)
 	if err != nil {
 		return 0, err
 	}
 
 	value, err := strconv.Atoi(data)
 	if err != nil {
-		return 0, fmt.Errorf("invalid ticker value: %s", data)
+		return 0, fmt.Errorf("%w: invalid ticker value: %s", ErrInvalidResponse, data)
 	}
 
 	return value, nil
@@ -141,22 +214,57 @@ func (s *TickerSlot) Reset(value int) error {
 	return s.client.Write(s.slot, strconv.Itoa(value))
 }
 
+// ResetContext resets the ticker to the specified value until the context is done
+func (s *TickerSlot) ResetContext(ctx context.Context, value int) error {
+	return s.client.WriteContext(ctx, s.slot, strconv.Itoa(value))
+}
+
 // AtomicCounterSlot provides methods for interacting with an atomic counter slot
 type AtomicCounterSlot struct {
-	client *Client
+	client backend
 	slot   int
 }
 
 // Read reads the current value of the counter
 func (s *AtomicCounterSlot) Read() (int, error) {
-	data, err := s.client.Read(s.slot)
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.ReadContext(ctx)
+}
+
+// ReadContext reads the current value of the counter until the context is done
+func (s *AtomicCounterSlot) ReadContext(ctx context.Context) (int, error) {
+	data, err := s.client.ReadContext(ctx, s.slot)
 	if err != nil {
 		return 0, err
 	}
 
 	value, err := strconv.Atoi(data)
 	if err != nil {
-		return 0, fmt.Errorf("invalid counter value: %s", data)
+		return 0, fmt.Errorf("%w: invalid counter value: %s", ErrInvalidResponse, data)
+	}
+
+	return value, nil
+}
+
+// Add adds delta to the counter and returns its new value
+func (s *AtomicCounterSlot) Add(delta int) (int, error) {
+	ctx, cancel := s.client.timeoutContext()
+	defer cancel()
+	return s.AddContext(ctx, delta)
+}
+
+// AddContext adds delta to the counter and returns its new value until the
+// context is done
+func (s *AtomicCounterSlot) AddContext(ctx context.Context, delta int) (int, error) {
+	data, err := s.client.write(ctx, s.slot, strconv.Itoa(delta))
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(data)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid counter value: %s", ErrInvalidResponse, data)
 	}
 
 	return value, nil
@@ -164,35 +272,91 @@ func (s *AtomicCounterSlot) Read() (int, error) {
 
 // Increment increments the counter by the specified value
 func (s *AtomicCounterSlot) Increment(value int) error {
-	return s.client.Write(s.slot, strconv.Itoa(value))
+	_, err := s.Add(value)
+	return err
+}
+
+// IncrementContext increments the counter by the specified value until the context is done
+func (s *AtomicCounterSlot) IncrementContext(ctx context.Context, value int) error {
+	_, err := s.AddContext(ctx, value)
+	return err
 }
 
 // Decrement decrements the counter by the specified value
 func (s *AtomicCounterSlot) Decrement(value int) error {
-	return s.client.Write(s.slot, strconv.Itoa(-value))
+	_, err := s.Add(-value)
+	return err
+}
+
+// DecrementContext decrements the counter by the specified value until the context is done
+func (s *AtomicCounterSlot) DecrementContext(ctx context.Context, value int) error {
+	_, err := s.AddContext(ctx, -value)
+	return err
+}
+
+// SimpleMemory returns the simple memory slot with the given number
+func (c *Client) SimpleMemory(slot int) *SimpleMemorySlot {
+	return &SimpleMemorySlot{client: c, slot: slot}
 }
 
-// GetSlot returns a typed slot interface based on the slot type
+// TimeoutMemory returns the timeout memory slot with the given number
+func (c *Client) TimeoutMemory(slot int) *TimeoutMemorySlot {
+	return &TimeoutMemorySlot{client: c, slot: slot}
+}
+
+// TokenBucket returns the token bucket slot with the given number
+func (c *Client) TokenBucket(slot int) *TokenBucketSlot {
+	return &TokenBucketSlot{client: c, slot: slot}
+}
+
+// LeakyBucket returns the leaky bucket slot with the given number
+func (c *Client) LeakyBucket(slot int) *LeakyBucketSlot {
+	return &LeakyBucketSlot{client: c, slot: slot}
+}
+
+// BroadcastSlot returns the broadcast slot with the given number
+func (c *Client) BroadcastSlot(slot int) *BroadcastSlot {
+	return &BroadcastSlot{client: c, slot: slot}
+}
+
+// Ticker returns the ticker slot with the given number
+func (c *Client) Ticker(slot int) *TickerSlot {
+	return &TickerSlot{client: c, slot: slot}
+}
+
+// AtomicCounter returns the atomic counter slot with the given number
+func (c *Client) AtomicCounter(slot int) *AtomicCounterSlot {
+	return &AtomicCounterSlot{client: c, slot: slot}
+}
+
+// GetSlot returns a typed slot based on the slot type, for slot types only
+// known at runtime. The typed constructors such as SimpleMemory should be
+// preferred when the type is known.
 func (c *Client) GetSlot(slotType SlotType, slot int) (interface{}, error) {
-	if slot < 0 || slot > 999 {
-		return nil, fmt.Errorf("invalid slot number: %d", slot)
+	return getSlot(c, slotType, slot)
+}
+
+// getSlot returns a typed slot sending its commands through b
+func getSlot(b backend, slotType SlotType, slot int) (interface{}, error) {
+	if slot < 0 || slot > protocol.MaxSlot {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidSlot, slot)
 	}
 
 	switch slotType {
 	case SimpleMemory:
-		return &SimpleMemorySlot{client: c, slot: slot}, nil
+		return &SimpleMemorySlot{client: b, slot: slot}, nil
 	case TimeoutMemory:
-		return &TimeoutMemorySlot{client: c, slot: slot}, nil
+		return &TimeoutMemorySlot{client: b, slot: slot}, nil
 	case TokenBucket:
-		return &TokenBucketSlot{client: c, slot: slot}, nil
+		return &TokenBucketSlot{client: b, slot: slot}, nil
 	case LeakyBucket:
-		return &LeakyBucketSlot{client: c, slot: slot}, nil
+		return &LeakyBucketSlot{client: b, slot: slot}, nil
 	case Broadcast:
-		return &BroadcastSlot{client: c, slot: slot}, nil
+		return &BroadcastSlot{client: b, slot: slot}, nil
 	case Ticker:
-		return &TickerSlot{client: c, slot: slot}, nil
+		return &TickerSlot{client: b, slot: slot}, nil
 	case AtomicCounter:
-		return &AtomicCounterSlot{client: c, slot: slot}, nil
+		return &AtomicCounterSlot{client: b, slot: slot}, nil
 	default:
 		return nil, fmt.Errorf("unknown slot type: %s", slotType)
 	}
@@ -0,0 +1,143 @@
+package ghoti
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotTypes(t *testing.T) {
+	server := ghotitest.NewServer(
+		ghotitest.WithUser("service", "secret"),
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TimeoutMemory, Timeout: time.Minute}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(3, ghotitest.SlotConfig{Kind: ghotitest.LeakyBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(4, ghotitest.SlotConfig{Kind: ghotitest.Broadcast}),
+		ghotitest.WithSlot(5, ghotitest.SlotConfig{Kind: ghotitest.Ticker, Tick: time.Minute}),
+		ghotitest.WithSlot(6, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter}),
+	)
+	defer server.Close()
+
+	client, err := New(server.Addr(), WithAuth("service", "secret"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Run("simple memory", func(t *testing.T) {
+		memory := client.SimpleMemory(0)
+
+		require.NoError(t, memory.Write("value"))
+		value, err := memory.Read()
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	})
+
+	t.Run("timeout memory", func(t *testing.T) {
+		memory := client.TimeoutMemory(1)
+
+		require.NoError(t, memory.Write("lock"))
+		value, err := memory.Read()
+		require.NoError(t, err)
+		assert.Equal(t, "lock", value)
+	})
+
+	t.Run("token bucket", func(t *testing.T) {
+		bucket := client.TokenBucket(2)
+
+		tokens, err := bucket.GetTokens()
+		require.NoError(t, err)
+		assert.Equal(t, 0, tokens)
+
+		_, err = bucket.GetTokens()
+		var ghotiErr *model.GhotiError
+		require.True(t, errors.As(err, &ghotiErr))
+		assert.Equal(t, "008", ghotiErr.Code)
+	})
+
+	t.Run("leaky bucket", func(t *testing.T) {
+		bucket := client.LeakyBucket(3)
+
+		acquired, err := bucket.TryAcquire()
+		require.NoError(t, err)
+		assert.True(t, acquired)
+
+		acquired, err = bucket.TryAcquire()
+		require.NoError(t, err)
+		assert.False(t, acquired)
+	})
+
+	t.Run("broadcast", func(t *testing.T) {
+		listener, err := New(server.Addr())
+		require.NoError(t, err)
+		defer listener.Close()
+
+		received := make(chan string, 1)
+		listener.SetBroadcastHandler(func(slot int, data string) {
+			received <- data
+		})
+
+		slot, err := client.GetSlot(Broadcast, 4)
+		require.NoError(t, err)
+
+		delivered, total, failed, err := slot.(*BroadcastSlot).Send("hello")
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, 0, failed)
+		assert.Equal(t, "hello", <-received)
+	})
+
+	t.Run("ticker", func(t *testing.T) {
+		ticker := client.Ticker(5)
+
+		server.Advance(2 * time.Minute)
+		value, err := ticker.Read()
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+
+		require.NoError(t, ticker.Reset(0))
+		value, err = ticker.Read()
+		require.NoError(t, err)
+		assert.Equal(t, 0, value)
+	})
+
+	t.Run("atomic counter", func(t *testing.T) {
+		counter := client.AtomicCounter(6)
+
+		require.NoError(t, counter.Increment(5))
+		require.NoError(t, counter.Decrement(2))
+		value, err := counter.Read()
+		require.NoError(t, err)
+		assert.Equal(t, 3, value)
+	})
+}
+
+func TestGetSlot(t *testing.T) {
+	client := &Client{}
+
+	tests := map[SlotType]interface{}{
+		SimpleMemory:  client.SimpleMemory(1),
+		TimeoutMemory: client.TimeoutMemory(1),
+		TokenBucket:   client.TokenBucket(1),
+		LeakyBucket:   client.LeakyBucket(1),
+		Broadcast:     client.BroadcastSlot(1),
+		Ticker:        client.Ticker(1),
+		AtomicCounter: client.AtomicCounter(1),
+	}
+
+	for slotType, expected := range tests {
+		slot, err := client.GetSlot(slotType, 1)
+		require.NoError(t, err)
+		assert.Equal(t, expected, slot)
+	}
+
+	_, err := client.GetSlot(SimpleMemory, 1000)
+	assert.ErrorIs(t, err, ErrInvalidSlot)
+
+	_, err = client.GetSlot("unknown", 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package ghoti
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSubscriptionBuffer is the number of messages a subscription holds
+// until they are received
+const DefaultSubscriptionBuffer = 16
+
+// Message is a broadcast received from the server
+type Message struct {
+	Slot int
+	Data string
+}
+
+// Unsubscribe stops a subscription and closes its channel
+type Unsubscribe func()
+
+// OverflowPolicy decides what happens to a message that arrives while the
+// buffer of a subscription is full
+type OverflowPolicy int
+
+const (
+	// DropOldest drops the oldest buffered message to make room for the new one
+	DropOldest OverflowPolicy = iota
+	// DropNewest drops the new message
+	DropNewest
+	// Block waits until there is room for the message. It stops the client
+	// from reading responses meanwhile, so every request waits for the
+	// subscriber.
+	Block
+)
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*subscriber)
+
+// WithBuffer sets the number of messages the subscription holds until they
+// are received, at least one
+func WithBuffer(size int) SubscribeOption {
+	return func(s *subscriber) {
+		s.size = size
+	}
+}
+
+// WithOverflow sets what happens to messages that arrive while the buffer is
+// full, DropOldest by default
+func WithOverflow(policy OverflowPolicy) SubscribeOption {
+	return func(s *subscriber) {
+		s.policy = policy
+	}
+}
+
+// subscriber is a subscription to the broadcasts of a slot
+type subscriber struct {
+	slot   int
+	size   int
+	policy OverflowPolicy
+	ch     chan Message
+
+	// mutex keeps deliveries from racing with closing the channel, done
+	// releases a blocked delivery
+	mutex   sync.Mutex
+	done    chan struct{}
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// deliver sends a message to the subscriber following its overflow policy,
+// it returns false if the message was dropped
+func (s *subscriber) deliver(message Message) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return true
+	}
+
+	select {
+	case s.ch <- message:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- message:
+			return true
+		case <-s.done:
+			return true
+		}
+	case DropNewest:
+		s.dropped.Add(1)
+		return false
+	default:
+		// The receiver may take messages meanwhile, so keep trying
+		for {
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+
+			select {
+			case s.ch <- message:
+				return false
+			default:
+			}
+		}
+	}
+}
+
+// close closes the channel of the subscriber, waiting for a delivery in
+// progress to finish
+func (s *subscriber) close() {
+	close(s.done)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.closed = true
+	close(s.ch)
+}
+
+// Subscribe returns a channel receiving the broadcasts sent to a slot. Every
+// subscriber gets its own copy of the messages, buffered until they are
+// received. The channel is closed by Unsubscribe or when the client is closed.
+func (c *Client) Subscribe(slot int, opts ...SubscribeOption) (<-chan Message, Unsubscribe) {
+	s := &subscriber{
+		slot:   slot,
+		size:   DefaultSubscriptionBuffer,
+		policy: DropOldest,
+		done:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.ch = make(chan Message, max(s.size, 1))
+
+	c.mutex.Lock()
+	select {
+	case <-c.done:
+		// Nothing will be delivered to a closed client
+		c.mutex.Unlock()
+		s.close()
+		return s.ch, func() {}
+	default:
+	}
+	if c.subscribers == nil {
+		c.subscribers = make(map[int][]*subscriber)
+	}
+	c.subscribers[slot] = append(c.subscribers[slot], s)
+	c.mutex.Unlock()
+
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			if c.unsubscribe(s) {
+				s.close()
+			}
+		})
+	}
+}
+
+// Subscribe returns a channel receiving the broadcasts sent to the slot
+func (s *BroadcastSlot) Subscribe(opts ...SubscribeOption) (<-chan Message, Unsubscribe) {
+	return s.client.Subscribe(s.slot, opts...)
+}
+
+// Dropped returns the number of broadcasts of a slot that were dropped
+// because the buffer of a subscription was full, by the subscriptions that
+// are still active
+func (c *Client) Dropped(slot int) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var dropped uint64
+	for _, s := range c.subscribers[slot] {
+		dropped += s.dropped.Load()
+	}
+	return dropped
+}
+
+// unsubscribe removes a subscriber, it returns false if it was already
+// removed by closing the client
+func (c *Client) unsubscribe(s *subscriber) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	subscribers := c.subscribers[s.slot]
+	for i, other := range subscribers {
+		if other == s {
+			c.subscribers[s.slot] = append(subscribers[:i:i], subscribers[i+1:]...)
+			if len(c.subscribers[s.slot]) == 0 {
+				delete(c.subscribers, s.slot)
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// publish delivers a broadcast to the subscribers of its slot, it returns
+// false if there are none
+func (c *Client) publish(message Message) bool {
+	c.mutex.Lock()
+	subscribers := c.subscribers[message.Slot]
+	c.mutex.Unlock()
+
+	for _, s := range subscribers {
+		if !s.deliver(message) {
+			c.logger.Debug("broadcast dropped, subscription buffer full", slog.Int("slot", message.Slot))
+		}
+	}
+
+	return len(subscribers) > 0
+}
+
+// closeSubscribers closes the channels of every subscriber
+func (c *Client) closeSubscribers() {
+	c.mutex.Lock()
+	subscribers := c.subscribers
+	c.subscribers = nil
+	c.mutex.Unlock()
+
+	for _, slot := range subscribers {
+		for _, s := range slot {
+			s.close()
+		}
+	}
+}
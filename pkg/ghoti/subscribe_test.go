@@ -0,0 +1,121 @@
+package ghoti
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBroadcastClients starts a server with broadcast slots 1 and 2 and
+// returns a sender and a receiver connected to it
+func newBroadcastClients(t *testing.T) (*Client, *Client) {
+	server := ghotitest.NewServer(
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.Broadcast}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.Broadcast}),
+	)
+	t.Cleanup(server.Close)
+
+	sender, err := New(server.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { sender.Close() })
+
+	receiver, err := New(server.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { receiver.Close() })
+
+	return sender, receiver
+}
+
+func TestSubscribe(t *testing.T) {
+	sender, receiver := newBroadcastClients(t)
+
+	first, unsubscribeFirst := receiver.Subscribe(1)
+	second, unsubscribeSecond := receiver.BroadcastSlot(1).Subscribe()
+	other, unsubscribeOther := receiver.Subscribe(2)
+	defer unsubscribeSecond()
+	defer unsubscribeOther()
+
+	_, _, _, err := sender.Broadcast(1, "hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, Message{Slot: 1, Data: "hello"}, receive(t, first))
+	assert.Equal(t, Message{Slot: 1, Data: "hello"}, receive(t, second))
+	assert.Empty(t, other)
+
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, open := <-first
+	assert.False(t, open)
+
+	_, _, _, err = sender.Broadcast(1, "again")
+	require.NoError(t, err)
+	assert.Equal(t, "again", receive(t, second).Data)
+}
+
+func TestSubscribeOverflow(t *testing.T) {
+	sender, receiver := newBroadcastClients(t)
+
+	oldest, unsubscribeOldest := receiver.Subscribe(1, WithBuffer(1), WithOverflow(DropOldest))
+	newest, unsubscribeNewest := receiver.Subscribe(1, WithBuffer(1), WithOverflow(DropNewest))
+	defer unsubscribeOldest()
+	defer unsubscribeNewest()
+
+	for _, data := range []string{"1", "2", "3"} {
+		_, _, _, err := sender.Broadcast(1, data)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return receiver.Dropped(1) == 4
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, "3", receive(t, oldest).Data)
+	assert.Equal(t, "1", receive(t, newest).Data)
+	assert.Equal(t, uint64(0), receiver.Dropped(2))
+}
+
+func TestSubscribeBlock(t *testing.T) {
+	sender, receiver := newBroadcastClients(t)
+
+	messages, unsubscribe := receiver.Subscribe(1, WithBuffer(1), WithOverflow(Block))
+
+	for _, data := range []string{"1", "2", "3"} {
+		_, _, _, err := sender.Broadcast(1, data)
+		require.NoError(t, err)
+	}
+
+	for _, data := range []string{"1", "2", "3"} {
+		assert.Equal(t, data, receive(t, messages).Data)
+	}
+	assert.Equal(t, uint64(0), receiver.Dropped(1))
+
+	// A blocked delivery is released by unsubscribing
+	_, _, _, err := sender.Broadcast(1, "4")
+	require.NoError(t, err)
+	_, _, _, err = sender.Broadcast(1, "5")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	unsubscribe()
+
+	_, err = receiver.Read(0)
+	require.NoError(t, err)
+}
+
+func TestSubscribeClose(t *testing.T) {
+	_, receiver := newBroadcastClients(t)
+
+	messages, unsubscribe := receiver.Subscribe(1)
+	require.NoError(t, receiver.Close())
+
+	_, open := <-messages
+	assert.False(t, open)
+	unsubscribe()
+
+	messages, _ = receiver.Subscribe(1)
+	_, open = <-messages
+	assert.False(t, open)
+}
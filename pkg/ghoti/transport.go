@@ -0,0 +1,106 @@
+package ghoti
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Dialer opens connections to the server. *net.Dialer and *tls.Dialer
+// implement it, and DialerFunc adapts any function that creates a net.Conn.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// DialerFunc is a function that implements Dialer
+type DialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// DialContext calls the function
+func (f DialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
+
+// DialerConfig can be implemented by a ClientConfig to change how the client
+// connects to the server
+type DialerConfig interface {
+	Dialer() Dialer
+}
+
+// TLSConfig configures a TLS connection to the server
+type TLSConfig struct {
+	// CAFile is a PEM bundle with the certificates used to verify the server,
+	// the system pool is used when it is empty
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key used for
+	// mutual TLS, both must be set to enable it
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name used to verify the server certificate
+	ServerName string
+	// MinVersion is the minimum TLS version accepted, such as tls.VersionTLS12
+	MinVersion uint16
+	// InsecureSkipVerify disables the server certificate verification, it must
+	// only be used for development
+	InsecureSkipVerify bool
+}
+
+// Build creates the crypto/tls configuration, loading the files it refers to
+func (t *TLSConfig) Build() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		MinVersion:         t.MinVersion,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates found in %s", ErrInvalidConfig, t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("%w: both certificate and key files are required for TLS", ErrInvalidConfig)
+	}
+
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// dialerFor returns the dialer configured by a ClientConfig, or a plain
+// network dialer if it doesn't configure one
+func dialerFor(c ClientConfig) Dialer {
+	if dialerConfig, ok := c.(DialerConfig); ok {
+		if dialer := dialerConfig.Dialer(); dialer != nil {
+			return dialer
+		}
+	}
+	return &net.Dialer{}
+}
+
+// dial opens a new connection to the server
+func (c *Client) dial() (net.Conn, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.dialer.DialContext(ctx, c.config.Protocol(), c.config.Server())
+}
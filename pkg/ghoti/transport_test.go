@@ -0,0 +1,177 @@
+package ghoti
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert is a generated certificate with its PEM files on disk
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	tls      tls.Certificate
+	certFile string
+	keyFile  string
+}
+
+// generateCert creates a certificate signed by parent, or self signed if
+// parent is nil
+func generateCert(t *testing.T, name string, parent *testCert, isCA bool) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{name},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, tls: pair, certFile: certFile, keyFile: keyFile}
+}
+
+// tlsEchoServer runs the echo server behind TLS, requiring a client
+// certificate signed by the CA when mutual is set
+func tlsEchoServer(t *testing.T, ca, server *testCert, mutual bool) net.Listener {
+	config := &tls.Config{Certificates: []tls.Certificate{server.tls}}
+	if mutual {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	serveEcho(t, listener)
+
+	return listener
+}
+
+func TestClientTLS(t *testing.T) {
+	ca := generateCert(t, "ca", nil, true)
+	server := generateCert(t, "ghoti.test", ca, false)
+	listener := tlsEchoServer(t, ca, server, false)
+
+	client, err := New(listener.Addr().String(),
+		WithTLS(TLSConfig{CAFile: ca.certFile, ServerName: "ghoti.test"}),
+		WithAuth("test_user", "test_pass"),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.Read(8)
+	require.NoError(t, err)
+	assert.Equal(t, "008", value)
+
+	// The server certificate is not trusted without the CA
+	_, err = New(listener.Addr().String(), WithTLS(TLSConfig{ServerName: "ghoti.test"}))
+	assert.Error(t, err)
+}
+
+func TestClientMutualTLS(t *testing.T) {
+	ca := generateCert(t, "ca", nil, true)
+	server := generateCert(t, "ghoti.test", ca, false)
+	clientCert := generateCert(t, "client", ca, false)
+	listener := tlsEchoServer(t, ca, server, true)
+
+	client, err := New(listener.Addr().String(), WithTLS(TLSConfig{
+		CAFile:     ca.certFile,
+		CertFile:   clientCert.certFile,
+		KeyFile:    clientCert.keyFile,
+		ServerName: "ghoti.test",
+		MinVersion: tls.VersionTLS13,
+	}))
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.Read(9)
+	require.NoError(t, err)
+	assert.Equal(t, "009", value)
+
+	// Without a client certificate the server drops the connection
+	client, err = New(listener.Addr().String(),
+		WithTLS(TLSConfig{CAFile: ca.certFile, ServerName: "ghoti.test"}),
+		WithReconnectPolicy(ReconnectPolicy{Disabled: true}),
+	)
+	if err == nil {
+		defer client.Close()
+		_, err = client.Read(9)
+	}
+	assert.Error(t, err)
+}
+
+func TestTLSConfigBuildErrors(t *testing.T) {
+	_, err := (&TLSConfig{CAFile: "missing.pem"}).Build()
+	assert.Error(t, err)
+
+	_, err = (&TLSConfig{CertFile: "client.crt"}).Build()
+	assert.Error(t, err)
+
+	_, err = NewConfig("localhost:9090", WithTLS(TLSConfig{CAFile: "missing.pem"}))
+	assert.Error(t, err)
+}
+
+func TestClientCustomDialer(t *testing.T) {
+	listener, _ := echoServer(t)
+
+	dialed := 0
+	dialer := DialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed++
+		return (&net.Dialer{}).DialContext(ctx, "tcp", listener.Addr().String())
+	})
+
+	client, err := New("ignored:1", WithDialer(dialer))
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.Read(1)
+	require.NoError(t, err)
+	assert.Equal(t, "001", value)
+	assert.Equal(t, 1, dialed)
+}
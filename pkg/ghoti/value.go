@@ -0,0 +1,76 @@
+package ghoti
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// SetValueCodec sets the codec used by WriteBytes and ReadBytes on a slot, a
+// nil codec goes back to protocol.Text
+func (c *Client) SetValueCodec(slot int, codec protocol.ValueCodec) error {
+	if slot < 0 || slot > protocol.MaxSlot {
+		return fmt.Errorf("%w: %d", ErrInvalidSlot, slot)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if codec == nil {
+		delete(c.valueCodecs, slot)
+		return nil
+	}
+	c.valueCodecs[slot] = codec
+	return nil
+}
+
+// ValueCodec returns the codec used by WriteBytes and ReadBytes on a slot
+func (c *Client) ValueCodec(slot int) protocol.ValueCodec {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if codec, ok := c.valueCodecs[slot]; ok {
+		return codec
+	}
+	return protocol.Text
+}
+
+// WriteBytes writes a value to a slot, encoded with the codec of the slot
+func (c *Client) WriteBytes(slot int, value []byte) error {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.WriteBytesContext(ctx, slot, value)
+}
+
+// WriteBytesContext writes a value to a slot, encoded with the codec of the
+// slot, waiting for the response until the context is done. Values the codec
+// can't encode are rejected with ErrInvalidValue, and values longer than 36
+// characters once encoded with ErrDataTooLong, before anything is sent.
+func (c *Client) WriteBytesContext(ctx context.Context, slot int, value []byte) error {
+	data, err := protocol.EncodeValue(c.ValueCodec(slot), value)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteContext(ctx, slot, data)
+}
+
+// ReadBytes reads the value of a slot, decoded with the codec of the slot
+func (c *Client) ReadBytes(slot int) ([]byte, error) {
+	ctx, cancel := c.timeoutContext()
+	defer cancel()
+	return c.ReadBytesContext(ctx, slot)
+}
+
+// ReadBytesContext reads the value of a slot, decoded with the codec of the
+// slot, waiting for the response until the context is done. Data the codec
+// can't decode is reported with ErrInvalidValue.
+func (c *Client) ReadBytesContext(ctx context.Context, slot int) ([]byte, error) {
+	data, err := c.ReadContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ValueCodec(slot).Decode(data)
+}
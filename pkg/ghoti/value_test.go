@@ -0,0 +1,90 @@
+package ghoti
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientBytes(t *testing.T) {
+	server := ghotitest.NewServer()
+	defer server.Close()
+
+	client, err := New(server.Addr(), WithValueCodec(1, protocol.Base64), WithValueCodec(2, protocol.Escaped))
+	require.NoError(t, err)
+	defer client.Close()
+
+	value := []byte{0, '\n', 0xff, 'a'}
+	require.NoError(t, client.WriteBytes(1, value))
+	assert.Equal(t, "AAr/YQ", server.Value(1))
+
+	read, err := client.ReadBytes(1)
+	require.NoError(t, err)
+	assert.Equal(t, value, read)
+
+	require.NoError(t, client.WriteBytes(2, []byte("line\nbreak")))
+	assert.Equal(t, `line\x0abreak`, server.Value(2))
+	read, err = client.ReadBytes(2)
+	require.NoError(t, err)
+	assert.Equal(t, "line\nbreak", string(read))
+
+	// Slots without a codec only take printable text
+	require.NoError(t, client.WriteBytes(3, []byte("plain")))
+	assert.Equal(t, "plain", server.Value(3))
+	err = client.WriteBytes(3, []byte("a\nb"))
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+
+	// The length is checked once encoded, before sending
+	err = client.WriteBytes(1, make([]byte, 28))
+	assert.True(t, errors.Is(err, ErrDataTooLong))
+	assert.Equal(t, "AAr/YQ", server.Value(1))
+
+	// Data written without the codec can't be decoded
+	require.NoError(t, client.Write(1, "not base64!"))
+	_, err = client.ReadBytes(1)
+	assert.True(t, errors.Is(err, ErrInvalidValue))
+}
+
+func TestClientSetValueCodec(t *testing.T) {
+	server := ghotitest.NewServer()
+	defer server.Close()
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	defer client.Close()
+
+	assert.Equal(t, protocol.Text, client.ValueCodec(5))
+
+	require.NoError(t, client.SetValueCodec(5, protocol.Hex))
+	assert.Equal(t, protocol.Hex, client.ValueCodec(5))
+	require.NoError(t, client.WriteBytes(5, []byte{0xca, 0xfe}))
+	assert.Equal(t, "cafe", server.Value(5))
+
+	require.NoError(t, client.SetValueCodec(5, nil))
+	assert.Equal(t, protocol.Text, client.ValueCodec(5))
+
+	err = client.SetValueCodec(1000, protocol.Hex)
+	assert.True(t, errors.Is(err, ErrInvalidSlot))
+}
+
+func TestPoolBytes(t *testing.T) {
+	server := ghotitest.NewServer()
+	defer server.Close()
+
+	cfg, err := NewConfig(server.Addr(), WithValueCodec(1, protocol.Base85))
+	require.NoError(t, err)
+	pool, err := NewPool(cfg)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	require.NoError(t, pool.WriteBytes(1, []byte{0, 0, 0, 0}))
+	assert.Equal(t, "z", server.Value(1))
+
+	value, err := pool.ReadBytes(1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 0, 0}, value)
+}
@@ -0,0 +1,247 @@
+package ghoti
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ScheduleOption configures the schedule of a Heartbeat or a Watchdog
+type ScheduleOption func(*schedule)
+
+// WithJitter spreads the runs randomly by up to the given fraction of the
+// interval, so many processes don't hit the server at the same time
+func WithJitter(fraction float64) ScheduleOption {
+	return func(s *schedule) {
+		s.jitter = fraction
+	}
+}
+
+// WithErrorHandler sets a function called with the errors of the runs, which
+// are only logged by default
+func WithErrorHandler(handler func(error)) ScheduleOption {
+	return func(s *schedule) {
+		s.onError = handler
+	}
+}
+
+// schedule runs a function on an interval until it is stopped
+type schedule struct {
+	client   backend
+	slot     int
+	interval time.Duration
+	jitter   float64
+	onError  func(error)
+	// err is set if the interval is not valid, it is returned when running
+	err error
+
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newSchedule creates a schedule with the options applied
+func newSchedule(client backend, slot int, interval time.Duration, opts []ScheduleOption) *schedule {
+	s := &schedule{client: client, slot: slot, interval: interval}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if interval <= 0 {
+		s.err = fmt.Errorf("%w: interval must be positive: %s", ErrInvalidConfig, interval)
+	}
+
+	return s
+}
+
+// next returns the time to wait until the next run
+func (s *schedule) next() time.Duration {
+	delay := float64(s.interval)
+
+	if s.jitter > 0 {
+		jitter := math.Min(s.jitter, 1) * delay
+		delay = delay - jitter + rand.Float64()*2*jitter
+	}
+
+	return time.Duration(delay)
+}
+
+// run calls fn right away and then on every interval until the context is done
+func (s *schedule) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			s.client.log().Warn("scheduled run failed", slog.Int("slot", s.slot), slog.String("error", err.Error()))
+			if s.onError != nil {
+				s.onError(err)
+			}
+		}
+
+		select {
+		case <-time.After(s.next()):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// start calls run in the background until stop is called or the context is done
+func (s *schedule) start(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.cancel != nil {
+		return nil
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		s.run(ctx, fn)
+	}(s.done)
+
+	return nil
+}
+
+// stop stops the background run and waits for it to finish
+func (s *schedule) stop() {
+	s.mutex.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mutex.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+}
+
+// Heartbeat resets a ticker on an interval to tell a Watchdog the process
+// is alive
+type Heartbeat struct {
+	slot     *TickerSlot
+	schedule *schedule
+}
+
+// Heartbeat returns a heartbeat resetting the ticker every interval. An
+// interval that is not positive makes Run and Start fail with
+// ErrInvalidConfig.
+func (s *TickerSlot) Heartbeat(interval time.Duration, opts ...ScheduleOption) *Heartbeat {
+	return &Heartbeat{slot: s, schedule: newSchedule(s.client, s.slot, interval, opts)}
+}
+
+// Run resets the ticker right away and then every interval until the
+// context is done, returning the error of the context
+func (h *Heartbeat) Run(ctx context.Context) error {
+	return h.schedule.run(ctx, h.beat)
+}
+
+// Start runs the heartbeat in the background until Stop is called or the
+// context is done. It only fails if the interval is not valid.
+func (h *Heartbeat) Start(ctx context.Context) error {
+	return h.schedule.start(ctx, h.beat)
+}
+
+// Stop stops a heartbeat started with Start
+func (h *Heartbeat) Stop() {
+	h.schedule.stop()
+}
+
+// beat resets the ticker
+func (h *Heartbeat) beat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.slot.client.requestTimeout())
+	defer cancel()
+	return h.slot.ResetContext(ctx, 0)
+}
+
+// WatchdogFunc is called when a ticker stalls, with stalled set to true, and
+// when it is reset again, with stalled set to false
+type WatchdogFunc func(stalled bool, ticks int)
+
+// Watchdog polls a ticker and calls a function when it stops being reset
+// before reaching a threshold
+type Watchdog struct {
+	slot      *TickerSlot
+	threshold int
+	fn        WatchdogFunc
+	schedule  *schedule
+
+	mutex   sync.Mutex
+	stalled bool
+}
+
+// Watchdog returns a watchdog reading the ticker every interval and calling
+// fn when its value reaches the threshold, and again when it goes below it.
+// An interval that is not positive makes Run and Start fail with
+// ErrInvalidConfig.
+func (s *TickerSlot) Watchdog(threshold int, interval time.Duration, fn WatchdogFunc, opts ...ScheduleOption) *Watchdog {
+	return &Watchdog{
+		slot:      s,
+		threshold: threshold,
+		fn:        fn,
+		schedule:  newSchedule(s.client, s.slot, interval, opts),
+	}
+}
+
+// Run polls the ticker until the context is done, returning the error of
+// the context
+func (w *Watchdog) Run(ctx context.Context) error {
+	return w.schedule.run(ctx, w.check)
+}
+
+// Start polls the ticker in the background until Stop is called or the
+// context is done. It only fails if the interval is not valid.
+func (w *Watchdog) Start(ctx context.Context) error {
+	return w.schedule.start(ctx, w.check)
+}
+
+// Stop stops a watchdog started with Start
+func (w *Watchdog) Stop() {
+	w.schedule.stop()
+}
+
+// Stalled tells if the ticker reached the threshold in the last poll
+func (w *Watchdog) Stalled() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.stalled
+}
+
+// check reads the ticker and calls the function if it crossed the threshold
+func (w *Watchdog) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.slot.client.requestTimeout())
+	defer cancel()
+
+	ticks, err := w.slot.ReadContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	stalled := ticks >= w.threshold
+
+	w.mutex.Lock()
+	changed := stalled != w.stalled
+	w.stalled = stalled
+	w.mutex.Unlock()
+
+	if changed {
+		w.fn(stalled, ticks)
+	}
+
+	return nil
+}
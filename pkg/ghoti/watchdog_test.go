@@ -0,0 +1,130 @@
+package ghoti
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTickerClient starts a server with a ticker on slot 1 ticking every
+// minute, starting at the given value
+func newTickerClient(t *testing.T, value string) (*ghotitest.Server, *Client) {
+	server := ghotitest.NewServer(
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.Ticker, Tick: time.Minute, Value: value}),
+	)
+	t.Cleanup(server.Close)
+
+	client, err := New(server.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+func TestHeartbeat(t *testing.T) {
+	server, client := newTickerClient(t, "5")
+
+	heartbeat := client.Ticker(1).Heartbeat(5*time.Millisecond, WithJitter(0.5))
+	require.NoError(t, heartbeat.Start(context.Background()))
+
+	require.Eventually(t, func() bool { return server.Value(1) == "0" }, time.Second, time.Millisecond)
+
+	server.Advance(2 * time.Minute)
+	require.Eventually(t, func() bool { return server.Value(1) == "0" }, time.Second, time.Millisecond)
+
+	heartbeat.Stop()
+	heartbeat.Stop()
+
+	server.Advance(2 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "2", server.Value(1))
+}
+
+func TestHeartbeatRun(t *testing.T) {
+	_, client := newTickerClient(t, "0")
+	require.NoError(t, client.Close())
+
+	errs := make(chan error, 1)
+	heartbeat := client.Ticker(1).Heartbeat(time.Millisecond, WithErrorHandler(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- heartbeat.Run(ctx)
+	}()
+
+	assert.ErrorIs(t, receive(t, errs), ErrClosed)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+}
+
+func TestWatchdog(t *testing.T) {
+	server, client := newTickerClient(t, "0")
+
+	type event struct {
+		stalled bool
+		ticks   int
+	}
+	events := make(chan event, 4)
+
+	ticker := client.Ticker(1)
+	watchdog := ticker.Watchdog(3, 5*time.Millisecond, func(stalled bool, ticks int) {
+		events <- event{stalled, ticks}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, watchdog.Start(ctx))
+	defer watchdog.Stop()
+
+	server.Advance(2 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, watchdog.Stalled())
+	assert.Empty(t, events)
+
+	server.Advance(time.Minute)
+	assert.Equal(t, event{stalled: true, ticks: 3}, receive(t, events))
+	assert.True(t, watchdog.Stalled())
+
+	require.NoError(t, ticker.Reset(0))
+	assert.Equal(t, event{stalled: false, ticks: 0}, receive(t, events))
+	assert.False(t, watchdog.Stalled())
+}
+
+func TestScheduleJitter(t *testing.T) {
+	s := newSchedule(nil, 0, 100*time.Millisecond, []ScheduleOption{WithJitter(0.2)})
+
+	for i := 0; i < 100; i++ {
+		delay := s.next()
+		assert.GreaterOrEqual(t, delay, 80*time.Millisecond)
+		assert.LessOrEqual(t, delay, 120*time.Millisecond)
+	}
+
+	assert.Equal(t, 100*time.Millisecond, newSchedule(nil, 0, 100*time.Millisecond, nil).next())
+}
+
+func TestScheduleInvalidInterval(t *testing.T) {
+	_, client := newTickerClient(t, "0")
+	ticker := client.Ticker(1)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		heartbeat := ticker.Heartbeat(interval)
+		assert.ErrorIs(t, heartbeat.Start(context.Background()), ErrInvalidConfig)
+		assert.ErrorIs(t, heartbeat.Run(context.Background()), ErrInvalidConfig)
+		heartbeat.Stop()
+
+		watchdog := ticker.Watchdog(1, interval, func(bool, int) {})
+		assert.ErrorIs(t, watchdog.Start(context.Background()), ErrInvalidConfig)
+		assert.ErrorIs(t, watchdog.Run(context.Background()), ErrInvalidConfig)
+		watchdog.Stop()
+	}
+}
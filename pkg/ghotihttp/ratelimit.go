@@ -0,0 +1,228 @@
+// Package ghotihttp provides net/http middleware backed by a Ghoti server.
+//
+// RateLimit limits the requests of a handler with token or leaky bucket
+// slots, so the limit is shared by every instance of the service:
+//
+//	limited := ghotihttp.RateLimit(handler, ghotihttp.Options{
+//		Client: client,
+//		Key:    ghotihttp.ByIP,
+//		Slot:   ghotihttp.Hash(10, 11, 12, 13),
+//		Limit:  100,
+//	})
+package ghotihttp
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+)
+
+// KeyFunc maps a request to the key it is limited by
+type KeyFunc func(r *http.Request) string
+
+// ByIP limits requests by the IP of the client
+func ByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ByHeader limits requests by the value of a header, such as an API key
+func ByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// ByRoute limits requests by method and path
+func ByRoute(r *http.Request) string {
+	return r.Method + " " + r.URL.Path
+}
+
+// SlotFunc maps a key to the slot holding its bucket
+type SlotFunc func(key string) int
+
+// Fixed limits every key with the same slot
+func Fixed(slot int) SlotFunc {
+	return func(string) int {
+		return slot
+	}
+}
+
+// Hash spreads the keys over the given slots, so each slot limits a share
+// of the keys. It panics if no slot is given.
+func Hash(slots ...int) SlotFunc {
+	if len(slots) == 0 {
+		panic("ghotihttp: hash without slots")
+	}
+
+	return func(key string) int {
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		return slots[hash.Sum32()%uint32(len(slots))]
+	}
+}
+
+// Map limits the keys in the map with their slot and any other key with the
+// fallback slot
+func Map(slots map[string]int, fallback int) SlotFunc {
+	return func(key string) int {
+		if slot, ok := slots[key]; ok {
+			return slot
+		}
+		return fallback
+	}
+}
+
+// Options configures RateLimit
+type Options struct {
+	// Client is the client used to reach the server
+	Client *ghoti.Client
+	// Kind is the type of the slots, ghoti.TokenBucket or ghoti.LeakyBucket.
+	// Token buckets are used by default.
+	Kind ghoti.SlotType
+	// Key maps a request to the key it is limited by, ByIP by default
+	Key KeyFunc
+	// Slot maps a key to its slot
+	Slot SlotFunc
+	// Limit is the capacity of the buckets, sent in the X-RateLimit-Limit
+	// header when it is set
+	Limit int
+	// RetryAfter is the time a denied client should wait, usually the refill
+	// rate of the buckets. One second by default.
+	RetryAfter time.Duration
+	// Timeout bounds the time spent checking the limit of a request, the
+	// timeout of the client by default. A server that doesn't answer in time
+	// is handled like one that can't be reached.
+	Timeout time.Duration
+	// FailOpen lets requests through when the server can't be reached or
+	// fails, otherwise they are rejected with 503 Service Unavailable
+	FailOpen bool
+	// Denied writes the response to denied requests, a 429 Too Many Requests
+	// by default. The rate limit headers are already set when it is called.
+	Denied http.Handler
+}
+
+// rateLimiter is the middleware created by RateLimit
+type rateLimiter struct {
+	next     http.Handler
+	opts     Options
+	limiters sync.Map
+}
+
+// RateLimit limits the requests that reach the handler. It panics if the
+// options have no client or slot function, like http.Handle panics on an
+// invalid pattern.
+func RateLimit(next http.Handler, opts Options) http.Handler {
+	if opts.Client == nil {
+		panic("ghotihttp: rate limit without client")
+	}
+	if opts.Slot == nil {
+		panic("ghotihttp: rate limit without slot function")
+	}
+
+	if opts.Kind == "" {
+		opts.Kind = ghoti.TokenBucket
+	}
+	if opts.Kind != ghoti.TokenBucket && opts.Kind != ghoti.LeakyBucket {
+		panic("ghotihttp: rate limit slots must be token or leaky buckets")
+	}
+	if opts.Timeout < 0 {
+		panic("ghotihttp: rate limit timeout can't be negative")
+	}
+	if opts.Key == nil {
+		opts.Key = ByIP
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = time.Second
+	}
+	if opts.Denied == nil {
+		opts.Denied = http.HandlerFunc(tooManyRequests)
+	}
+
+	return &rateLimiter{next: next, opts: opts}
+}
+
+// ServeHTTP takes a token for the request and calls the next handler if it got one
+func (l *rateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limiter := l.limiter(l.opts.Slot(l.opts.Key(r)))
+
+	// The limiter bounds its requests with the timeout of the client
+	ctx := r.Context()
+	if l.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.opts.Timeout)
+		defer cancel()
+	}
+
+	// Errors and fallback denials mean the limit couldn't be checked, the
+	// fallback already let the request through if failing open
+	reservation, err := limiter.Reserve(ctx)
+	if err != nil && l.opts.FailOpen {
+		l.next.ServeHTTP(w, r)
+		return
+	}
+	if err != nil || (reservation.Fallback && !reservation.OK) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	header := w.Header()
+	if l.opts.Limit > 0 {
+		header.Set("X-RateLimit-Limit", strconv.Itoa(l.opts.Limit))
+	}
+	if reservation.Remaining >= 0 {
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(reservation.Remaining))
+	}
+
+	if !reservation.OK {
+		seconds := strconv.Itoa(int(math.Ceil(reservation.Delay.Seconds())))
+		header.Set("Retry-After", seconds)
+		header.Set("X-RateLimit-Reset", seconds)
+		l.opts.Denied.ServeHTTP(w, r)
+		return
+	}
+
+	l.next.ServeHTTP(w, r)
+}
+
+// limiter returns the limiter of a slot, creating it the first time
+func (l *rateLimiter) limiter(slot int) ghoti.Limiter {
+	if limiter, ok := l.limiters.Load(slot); ok {
+		return limiter.(ghoti.Limiter)
+	}
+
+	fallback := ghoti.FailClosed
+	if l.opts.FailOpen {
+		fallback = ghoti.FailOpen
+	}
+
+	opts := []ghoti.LimiterOption{
+		ghoti.WithFallback(fallback),
+		ghoti.WithWaitInterval(l.opts.RetryAfter),
+	}
+
+	var limiter ghoti.Limiter
+	if l.opts.Kind == ghoti.LeakyBucket {
+		limiter = l.opts.Client.LeakyBucket(slot).Limiter(opts...)
+	} else {
+		limiter = l.opts.Client.TokenBucket(slot).Limiter(opts...)
+	}
+
+	actual, _ := l.limiters.LoadOrStore(slot, limiter)
+	return actual.(ghoti.Limiter)
+}
+
+// tooManyRequests writes a 429 Too Many Requests response
+func tooManyRequests(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
@@ -0,0 +1,166 @@
+package ghotihttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghoti"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/ghotitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ok is the handler behind the middleware
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// newServer starts a server with the given slots and a client connected to it
+func newServer(t *testing.T, opts ...ghotitest.Option) (*ghotitest.Server, *ghoti.Client) {
+	server := ghotitest.NewServer(opts...)
+	t.Cleanup(server.Close)
+
+	client, err := ghoti.New(server.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return server, client
+}
+
+// get sends a request to the handler
+func get(handler http.Handler, remoteAddr string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	r.RemoteAddr = remoteAddr
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	_, client := newServer(t,
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.TokenBucket, Capacity: 2, Rate: time.Minute}),
+	)
+
+	handler := RateLimit(ok, Options{
+		Client:     client,
+		Slot:       Fixed(1),
+		Limit:      2,
+		RetryAfter: 1500 * time.Millisecond,
+	})
+
+	w := get(handler, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = get(handler, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = get(handler, "10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimitByKey(t *testing.T) {
+	_, client := newServer(t,
+		ghotitest.WithSlot(1, ghotitest.SlotConfig{Kind: ghotitest.LeakyBucket, Capacity: 1, Rate: time.Minute}),
+		ghotitest.WithSlot(2, ghotitest.SlotConfig{Kind: ghotitest.LeakyBucket, Capacity: 1, Rate: time.Minute}),
+	)
+
+	denied := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	handler := RateLimit(ok, Options{
+		Client: client,
+		Kind:   ghoti.LeakyBucket,
+		Key:    ByHeader("X-Api-Key"),
+		Slot:   Map(map[string]int{"first": 1}, 2),
+		Denied: denied,
+	})
+
+	first := map[string]string{"X-Api-Key": "first"}
+	second := map[string]string{"X-Api-Key": "second"}
+
+	assert.Equal(t, http.StatusOK, get(handler, "10.0.0.1:1", first).Code)
+	assert.Equal(t, http.StatusTeapot, get(handler, "10.0.0.1:1", first).Code)
+
+	w := get(handler, "10.0.0.1:1", second)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusTeapot, get(handler, "10.0.0.1:1", second).Code)
+}
+
+func TestRateLimitFailure(t *testing.T) {
+	_, client := newServer(t)
+	require.NoError(t, client.Close())
+
+	closed := RateLimit(ok, Options{Client: client, Slot: Fixed(1)})
+	assert.Equal(t, http.StatusServiceUnavailable, get(closed, "10.0.0.1:1", nil).Code)
+
+	open := RateLimit(ok, Options{Client: client, Slot: Fixed(1), FailOpen: true})
+	assert.Equal(t, http.StatusOK, get(open, "10.0.0.1:1", nil).Code)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/items/1", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Api-Key", "key")
+
+	assert.Equal(t, "10.0.0.1", ByIP(r))
+	assert.Equal(t, "key", ByHeader("X-Api-Key")(r))
+	assert.Equal(t, "POST /items/1", ByRoute(r))
+
+	slot := Hash(1, 2, 3)("10.0.0.1")
+	assert.Contains(t, []int{1, 2, 3}, slot)
+	assert.Equal(t, slot, Hash(1, 2, 3)("10.0.0.1"))
+}
+
+func TestRateLimitServerHangs(t *testing.T) {
+	server, client := newServer(t)
+	server.Pause()
+	t.Cleanup(server.Resume)
+
+	slowClient, err := ghoti.New(server.Addr(), ghoti.WithTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+	t.Cleanup(func() { slowClient.Close() })
+
+	tests := map[string]struct {
+		opts Options
+		code int
+	}{
+		"fail closed":                {Options{Client: client, Slot: Fixed(1), Timeout: 20 * time.Millisecond}, http.StatusServiceUnavailable},
+		"fail open":                  {Options{Client: client, Slot: Fixed(1), Timeout: 20 * time.Millisecond, FailOpen: true}, http.StatusOK},
+		"client timeout fail closed": {Options{Client: slowClient, Slot: Fixed(1)}, http.StatusServiceUnavailable},
+		"client timeout fail open":   {Options{Client: slowClient, Slot: Fixed(1), FailOpen: true}, http.StatusOK},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := httptest.NewServer(RateLimit(ok, test.opts))
+			defer handler.Close()
+
+			start := time.Now()
+			response, err := http.Get(handler.URL)
+			require.NoError(t, err)
+			response.Body.Close()
+
+			assert.Equal(t, test.code, response.StatusCode)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
+
+func TestHashWithoutSlots(t *testing.T) {
+	assert.Panics(t, func() { Hash() })
+	assert.Equal(t, 7, Hash(7)("key"))
+}
@@ -0,0 +1,379 @@
+// Package ghotitest provides an in-process Ghoti server for tests.
+//
+// The server speaks the same line protocol as Ghoti and emulates every slot
+// type, including user permissions, so clients can be tested without a real
+// server:
+//
+//	server := ghotitest.NewServer(
+//		ghotitest.WithUser("service", "secret"),
+//		ghotitest.WithSlot(5, ghotitest.SlotConfig{Kind: ghotitest.AtomicCounter}),
+//	)
+//	defer server.Close()
+//
+//	client, err := ghoti.New(server.Addr(), ghoti.WithAuth("service", "secret"))
+package ghotitest
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/protocol"
+)
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithUser adds a user that can authenticate with the server
+func WithUser(name, pass string) Option {
+	return func(s *Server) {
+		s.users[name] = pass
+	}
+}
+
+// WithSlot configures a slot of the server. Slots that are not configured
+// behave as simple memory slots open to everyone.
+func WithSlot(number int, config SlotConfig) Option {
+	return func(s *Server) {
+		s.slots[number] = newSlot(config, s.now())
+	}
+}
+
+// Server is an in-process Ghoti server listening on a local port
+type Server struct {
+	listener net.Listener
+
+	mutex  sync.Mutex
+	users  map[string]string
+	slots  map[int]*slot
+	conns  map[*conn]struct{}
+	offset time.Duration
+	// resumed is closed when a paused server answers commands again, it is
+	// nil while the server is not paused
+	resumed chan struct{}
+
+	wg sync.WaitGroup
+}
+
+// conn is a client connected to the server
+type conn struct {
+	net.Conn
+	writeMutex sync.Mutex
+	encoder    *protocol.Encoder
+	// user and pending are guarded by the mutex of the server
+	user    string
+	pending string
+}
+
+// send writes a message to the client
+func (c *conn) send(message protocol.Message) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return c.encoder.EncodeMessage(message)
+}
+
+// NewServer starts a server listening on a random local port. It panics if
+// it can't listen, like httptest.NewServer.
+func NewServer(opts ...Option) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("ghotitest: failed to listen: %v", err))
+	}
+
+	return NewServerWithListener(listener, opts...)
+}
+
+// NewServerWithListener starts a server accepting connections from the given
+// listener, for example a TLS listener
+func NewServerWithListener(listener net.Listener, opts ...Option) *Server {
+	s := &Server{
+		listener: listener,
+		users:    make(map[string]string),
+		slots:    make(map[int]*slot),
+		conns:    make(map[*conn]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// SetSlot configures a slot while the server is running, resetting its state
+func (s *Server) SetSlot(number int, config SlotConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.slots[number] = newSlot(config, s.clock())
+}
+
+// SetUser adds a user or changes its password while the server is running
+func (s *Server) SetUser(name, pass string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users[name] = pass
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and disconnects every client
+func (s *Server) Close() {
+	s.listener.Close()
+	s.DropConnections()
+	s.Resume()
+	s.wg.Wait()
+}
+
+// Pause stops answering commands until Resume is called, keeping the
+// connections open, to simulate a server that hangs
+func (s *Server) Pause() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.resumed == nil {
+		s.resumed = make(chan struct{})
+	}
+}
+
+// Resume answers the commands received while the server was paused and
+// goes back to answering them as they arrive
+func (s *Server) Resume() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.resumed != nil {
+		close(s.resumed)
+		s.resumed = nil
+	}
+}
+
+// wait blocks while the server is paused
+func (s *Server) wait() {
+	s.mutex.Lock()
+	resumed := s.resumed
+	s.mutex.Unlock()
+
+	if resumed != nil {
+		<-resumed
+	}
+}
+
+// DropConnections disconnects every client without stopping the server, to
+// simulate a network failure or a server restart
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// Connections returns the number of connected clients
+func (s *Server) Connections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.conns)
+}
+
+// Advance moves the clock of the server forward, to expire locks, refill
+// buckets and tick tickers without waiting
+func (s *Server) Advance(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.offset += d
+}
+
+// Value returns the raw value stored in a slot, without consuming tokens or
+// expiring locks
+func (s *Server) Value(number int) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	slot, ok := s.slots[number]
+	if !ok {
+		return ""
+	}
+
+	switch slot.config.Kind {
+	case TokenBucket, LeakyBucket:
+		return strconv.Itoa(slot.level)
+	case AtomicCounter:
+		return strconv.Itoa(slot.counter)
+	case Ticker:
+		return strconv.Itoa(slot.ticks(s.clock()))
+	default:
+		return slot.value
+	}
+}
+
+// now returns the time of the server clock
+func (s *Server) now() time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.clock()
+}
+
+// clock returns the time of the server clock, it must be called with the mutex held
+func (s *Server) clock() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// serve accepts connections until the listener is closed
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &conn{Conn: netConn, encoder: protocol.NewEncoder(netConn)}
+
+		s.mutex.Lock()
+		s.conns[c] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+// handle reads and answers the commands of a client
+func (s *Server) handle(c *conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, c)
+		s.mutex.Unlock()
+		c.Close()
+	}()
+
+	decoder := protocol.NewDecoder(c)
+	for {
+		cmd, err := decoder.DecodeCommand()
+		if err != nil {
+			// Invalid commands are answered with their error code, other
+			// errors come from the connection
+			code, ok := model.CodeOf(err)
+			if !ok {
+				return
+			}
+			s.wait()
+			if err := c.send(protocol.Error(code)); err != nil {
+				return
+			}
+			continue
+		}
+
+		s.wait()
+		if err := c.send(s.execute(c, cmd)); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs a command and returns the response
+func (s *Server) execute(c *conn, cmd protocol.Command) protocol.Message {
+	switch cmd.Op {
+	case protocol.OpUser:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		c.pending = cmd.Data
+		return protocol.Value(c.pending)
+	case protocol.OpPass:
+		// The user of every connection is read under the mutex by the
+		// goroutines sending broadcasts
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		pass, ok := s.users[c.pending]
+		if !ok || c.pending == "" || pass != cmd.Data {
+			return protocol.Error(model.CodeInvalidCredentials)
+		}
+		c.user = c.pending
+		return protocol.Value(c.user)
+	default:
+		return s.executeSlot(c, cmd)
+	}
+}
+
+// executeSlot runs a read or write command on a slot
+func (s *Server) executeSlot(c *conn, cmd protocol.Command) protocol.Message {
+	op := byte(cmd.Op)
+
+	s.mutex.Lock()
+	now := s.clock()
+	slot, ok := s.slots[cmd.Slot]
+	if !ok {
+		slot = newSlot(SlotConfig{Kind: SimpleMemory}, now)
+		s.slots[cmd.Slot] = slot
+	}
+
+	if code := slot.access(c.user, op); code != "" {
+		s.mutex.Unlock()
+		return protocol.Error(code)
+	}
+
+	var value, code string
+	if cmd.Op == protocol.OpRead {
+		value, code = slot.read(now)
+	} else {
+		value, code = slot.write(c.user, cmd.Data, now)
+	}
+
+	var targets []*conn
+	if slot.config.Kind == Broadcast && cmd.Op == protocol.OpWrite && code == "" {
+		targets = s.listeners(c, slot)
+	}
+	s.mutex.Unlock()
+
+	if code != "" {
+		return protocol.Error(code)
+	}
+
+	if targets != nil {
+		value = broadcast(targets, cmd.Slot, cmd.Data)
+	}
+
+	return protocol.SlotValue(cmd.Slot, value)
+}
+
+// listeners returns the clients other than the sender that can read a
+// broadcast slot, it must be called with the mutex held
+func (s *Server) listeners(sender *conn, slot *slot) []*conn {
+	targets := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		if c != sender && slot.access(c.user, 'r') == "" {
+			targets = append(targets, c)
+		}
+	}
+	return targets
+}
+
+// broadcast sends a message to the given clients and returns the result with
+// format: received/total/failed
+func broadcast(targets []*conn, number int, data string) string {
+	received, failed := 0, 0
+	for _, c := range targets {
+		if err := c.send(protocol.Broadcast(number, data)); err != nil {
+			failed++
+			continue
+		}
+		received++
+	}
+
+	return fmt.Sprintf("%d/%d/%d", received, len(targets), failed)
+}
@@ -0,0 +1,197 @@
+package ghotitest
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConn is a raw protocol connection to the server
+type testConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dial(t *testing.T, server *Server) *testConn {
+	conn, err := net.Dial("tcp", server.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testConn{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// do sends a command and returns the response line
+func (c *testConn) do(cmd string) string {
+	_, err := c.conn.Write([]byte(cmd + "\n"))
+	require.NoError(c.t, err)
+	return c.next()
+}
+
+// next returns the next line sent by the server
+func (c *testConn) next() string {
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := c.reader.ReadString('\n')
+	require.NoError(c.t, err)
+	return strings.TrimSuffix(line, "\n")
+}
+
+func TestSimpleMemory(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	c := dial(t, server)
+	assert.Equal(t, "v001", c.do("r001"))
+	assert.Equal(t, "v001hello", c.do("w001hello"))
+	assert.Equal(t, "v001hello", c.do("r001"))
+	assert.Equal(t, "e003", c.do("w001"+strings.Repeat("x", 37)))
+	assert.Equal(t, "e002", c.do("rabc"))
+	assert.Equal(t, "e001", c.do("x001"))
+}
+
+func TestAuthAndPermissions(t *testing.T) {
+	server := NewServer(
+		WithUser("reader", "pass1"),
+		WithUser("writer", "pass2"),
+		WithSlot(4, SlotConfig{Kind: SimpleMemory, Users: map[string]Permission{
+			"reader": Read,
+			"writer": ReadWrite,
+		}}),
+	)
+	defer server.Close()
+
+	c := dial(t, server)
+	assert.Equal(t, "e004", c.do("r004"))
+	assert.Equal(t, "vreader", c.do("ureader"))
+	assert.Equal(t, "e005", c.do("pwrong"))
+	assert.Equal(t, "vreader", c.do("ureader"))
+	assert.Equal(t, "vreader", c.do("ppass1"))
+	assert.Equal(t, "v004", c.do("r004"))
+	assert.Equal(t, "e006", c.do("w004data"))
+
+	c = dial(t, server)
+	c.do("uwriter")
+	c.do("ppass2")
+	assert.Equal(t, "v004data", c.do("w004data"))
+}
+
+func TestTimeoutMemory(t *testing.T) {
+	server := NewServer(
+		WithUser("a", "a"),
+		WithUser("b", "b"),
+		WithSlot(2, SlotConfig{Kind: TimeoutMemory, Timeout: time.Minute}),
+	)
+	defer server.Close()
+
+	a, b := dial(t, server), dial(t, server)
+	assert.Equal(t, "e004", a.do("w002lock"))
+	a.do("ua")
+	a.do("pa")
+	b.do("ub")
+	b.do("pb")
+
+	assert.Equal(t, "v002lock", a.do("w002lock"))
+	assert.Equal(t, "e007", b.do("w002mine"))
+	assert.Equal(t, "v002lock", b.do("r002"))
+
+	server.Advance(2 * time.Minute)
+	assert.Equal(t, "v002", b.do("r002"))
+	assert.Equal(t, "v002mine", b.do("w002mine"))
+
+	// The owner releases the slot by writing an empty value
+	assert.Equal(t, "v002", b.do("w002"))
+	assert.Equal(t, "v002lock", a.do("w002lock"))
+}
+
+func TestTokenBucket(t *testing.T) {
+	server := NewServer(WithSlot(3, SlotConfig{Kind: TokenBucket, Capacity: 2, Rate: time.Minute}))
+	defer server.Close()
+
+	c := dial(t, server)
+	assert.Equal(t, "v0031", c.do("r003"))
+	assert.Equal(t, "v0030", c.do("r003"))
+	assert.Equal(t, "e008", c.do("r003"))
+
+	server.Advance(time.Minute)
+	assert.Equal(t, "v0030", c.do("r003"))
+	assert.Equal(t, "e001", c.do("w0035"))
+}
+
+func TestLeakyBucket(t *testing.T) {
+	server := NewServer(WithSlot(3, SlotConfig{Kind: LeakyBucket, Capacity: 1, Rate: time.Minute}))
+	defer server.Close()
+
+	c := dial(t, server)
+	assert.Equal(t, "v0031", c.do("r003"))
+	assert.Equal(t, "v0030", c.do("r003"))
+
+	server.Advance(time.Minute)
+	assert.Equal(t, "v0031", c.do("r003"))
+}
+
+func TestBroadcast(t *testing.T) {
+	server := NewServer(WithSlot(7, SlotConfig{Kind: Broadcast}))
+	defer server.Close()
+
+	sender, listener := dial(t, server), dial(t, server)
+	assert.Equal(t, "v007", listener.do("r007"))
+
+	assert.Equal(t, "v0071/1/0", sender.do("w007hello"))
+	assert.Equal(t, "a007hello", listener.next())
+	assert.Equal(t, "v007hello", listener.do("r007"))
+}
+
+func TestTickerAndCounter(t *testing.T) {
+	server := NewServer(
+		WithSlot(8, SlotConfig{Kind: Ticker, Tick: time.Minute}),
+		WithSlot(9, SlotConfig{Kind: AtomicCounter}),
+	)
+	defer server.Close()
+
+	c := dial(t, server)
+	assert.Equal(t, "v0080", c.do("r008"))
+	server.Advance(3 * time.Minute)
+	assert.Equal(t, "v0083", c.do("r008"))
+	assert.Equal(t, "v0080", c.do("w0080"))
+	assert.Equal(t, "v0080", c.do("r008"))
+
+	assert.Equal(t, "v0095", c.do("w0095"))
+	assert.Equal(t, "v0093", c.do("w009-2"))
+	assert.Equal(t, "v0093", c.do("r009"))
+	assert.Equal(t, "3", server.Value(9))
+}
+
+func TestDropConnections(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	c := dial(t, server)
+	c.do("r001")
+	assert.Equal(t, 1, server.Connections())
+
+	server.DropConnections()
+	_, err := c.reader.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestPause(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	c := dial(t, server)
+	server.Pause()
+	_, err := c.conn.Write([]byte("w001hello\n"))
+	require.NoError(t, err)
+
+	c.conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	_, err = c.reader.ReadString('\n')
+	assert.Error(t, err, "a paused server doesn't answer")
+
+	server.Resume()
+	assert.Equal(t, "v001hello", c.next())
+}
@@ -0,0 +1,244 @@
+package ghotitest
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+// Kind is the type of a slot, it uses the same names as the Ghoti server
+type Kind string
+
+const (
+	// SimpleMemory stores a value that anyone with permission can read and write
+	SimpleMemory Kind = "simple_memory"
+	// TimeoutMemory stores a value that only the user who wrote it can
+	// overwrite until the timeout expires. Writing an empty value releases it.
+	TimeoutMemory Kind = "timeout_memory"
+	// TokenBucket hands out one token per read and refills over time
+	TokenBucket Kind = "token_bucket"
+	// LeakyBucket accepts one request per read while it is not full and leaks over time
+	LeakyBucket Kind = "leaky_bucket"
+	// Broadcast sends every written value to the other connected clients
+	Broadcast Kind = "broadcast"
+	// Ticker counts the ticks since it was last reset
+	Ticker Kind = "ticker"
+	// AtomicCounter adds every written number to its value
+	AtomicCounter Kind = "atomic_counter"
+)
+
+// Permission is the access a user has to a slot
+type Permission string
+
+const (
+	// Read allows reading the slot
+	Read Permission = "r"
+	// Write allows writing the slot
+	Write Permission = "w"
+	// ReadWrite allows reading and writing the slot
+	ReadWrite Permission = "rw"
+)
+
+// can tells if the permission allows the given command
+func (p Permission) can(cmd byte) bool {
+	switch cmd {
+	case 'r':
+		return p == Read || p == ReadWrite
+	case 'w':
+		return p == Write || p == ReadWrite
+	default:
+		return false
+	}
+}
+
+// SlotConfig configures a slot of the server
+type SlotConfig struct {
+	// Kind is the type of the slot
+	Kind Kind
+	// Users maps user names to their permission on the slot. A slot without
+	// users is open to everyone, including connections that didn't authenticate.
+	Users map[string]Permission
+	// Timeout is how long a timeout memory slot stays locked after a write
+	Timeout time.Duration
+	// Capacity is the size of a token or leaky bucket
+	Capacity int
+	// Rate is the time it takes a token bucket to get a token back or a
+	// leaky bucket to leak one request
+	Rate time.Duration
+	// Tick is the time between ticks of a ticker, one second by default
+	Tick time.Duration
+	// Value is the initial value of the slot
+	Value string
+}
+
+// slot is the state of a slot
+type slot struct {
+	config SlotConfig
+	value  string
+
+	// owner and expires hold the lock of a timeout memory slot
+	owner   string
+	expires time.Time
+
+	// level holds the tokens of a token bucket or the requests of a leaky
+	// bucket, updated holds when it was last refilled or leaked
+	level   int
+	updated time.Time
+
+	// counter holds the value of a counter or of a ticker at updated
+	counter int
+}
+
+// newSlot creates the state of a slot from its configuration
+func newSlot(config SlotConfig, now time.Time) *slot {
+	s := &slot{config: config, value: config.Value, updated: now}
+
+	switch config.Kind {
+	case TokenBucket:
+		s.level = config.Capacity
+	case Ticker, AtomicCounter:
+		s.counter, _ = strconv.Atoi(config.Value)
+	}
+
+	return s
+}
+
+// access returns the error code for a user running a command on the slot, or
+// an empty string if it is allowed
+func (s *slot) access(user string, cmd byte) string {
+	if len(s.config.Users) == 0 {
+		return ""
+	}
+
+	if user == "" {
+		return model.CodeAuthRequired
+	}
+
+	if !s.config.Users[user].can(cmd) {
+		return model.CodePermissionDenied
+	}
+
+	return ""
+}
+
+// read runs a read command, returning the value or an error code
+func (s *slot) read(now time.Time) (string, string) {
+	switch s.config.Kind {
+	case TokenBucket:
+		s.refill(now)
+		if s.level == 0 {
+			return "", model.CodeNoTokens
+		}
+		s.level--
+		return strconv.Itoa(s.level), ""
+	case LeakyBucket:
+		s.leak(now)
+		if s.level >= s.config.Capacity {
+			return "0", ""
+		}
+		s.level++
+		return "1", ""
+	case Ticker:
+		return strconv.Itoa(s.ticks(now)), ""
+	case AtomicCounter:
+		return strconv.Itoa(s.counter), ""
+	case TimeoutMemory:
+		if !s.expires.IsZero() && now.After(s.expires) {
+			s.owner, s.value = "", ""
+		}
+		return s.value, ""
+	default:
+		return s.value, ""
+	}
+}
+
+// write runs a write command, returning the response data or an error code.
+// Broadcasts are sent by the server.
+func (s *slot) write(user, data string, now time.Time) (string, string) {
+	switch s.config.Kind {
+	case TimeoutMemory:
+		if user == "" {
+			return "", model.CodeAuthRequired
+		}
+		if s.owner != "" && s.owner != user && now.Before(s.expires) {
+			return "", model.CodeSlotLocked
+		}
+		if data == "" {
+			s.owner, s.value, s.expires = "", "", time.Time{}
+			return "", ""
+		}
+		s.owner, s.value, s.expires = user, data, now.Add(s.config.Timeout)
+		return data, ""
+	case Ticker:
+		value, err := strconv.Atoi(data)
+		if err != nil {
+			return "", model.CodeInvalidCommand
+		}
+		s.counter, s.updated = value, now
+		return data, ""
+	case AtomicCounter:
+		delta, err := strconv.Atoi(data)
+		if err != nil {
+			return "", model.CodeInvalidCommand
+		}
+		s.counter += delta
+		return strconv.Itoa(s.counter), ""
+	case TokenBucket, LeakyBucket:
+		return "", model.CodeInvalidCommand
+	default:
+		s.value = data
+		return data, ""
+	}
+}
+
+// refill adds the tokens earned since the last refill
+func (s *slot) refill(now time.Time) {
+	if s.config.Rate <= 0 {
+		return
+	}
+
+	// A full bucket starts earning tokens once one is taken
+	if s.level >= s.config.Capacity {
+		s.updated = now
+		return
+	}
+
+	earned := int(now.Sub(s.updated) / s.config.Rate)
+	if earned == 0 {
+		return
+	}
+
+	s.level = min(s.config.Capacity, s.level+earned)
+	s.updated = s.updated.Add(time.Duration(earned) * s.config.Rate)
+}
+
+// leak removes the requests leaked since the last leak
+func (s *slot) leak(now time.Time) {
+	if s.config.Rate <= 0 {
+		return
+	}
+
+	// An empty bucket starts leaking once a request is added
+	if s.level == 0 {
+		s.updated = now
+		return
+	}
+
+	leaked := int(now.Sub(s.updated) / s.config.Rate)
+	if leaked == 0 {
+		return
+	}
+
+	s.level = max(0, s.level-leaked)
+	s.updated = s.updated.Add(time.Duration(leaked) * s.config.Rate)
+}
+
+// ticks returns the value of a ticker
+func (s *slot) ticks(now time.Time) int {
+	tick := s.config.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return s.counter + int(now.Sub(s.updated)/tick)
+}
@@ -1,6 +1,113 @@
 package model
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// Error codes returned by the Ghoti server
+const (
+	CodeInvalidCommand     = "001"
+	CodeInvalidSlot        = "002"
+	CodeInvalidData        = "003"
+	CodeAuthRequired       = "004"
+	CodeInvalidCredentials = "005"
+	CodePermissionDenied   = "006"
+	CodeSlotLocked         = "007"
+	CodeNoTokens           = "008"
+	CodeInternalError      = "009"
+)
+
+// Sentinel errors matched with errors.Is. A GhotiError matches the sentinel of
+// its code, and the client wraps the sentinels in the errors it returns.
+var (
+	// ErrInvalidCommand means the server didn't understand the command
+	ErrInvalidCommand = errors.New("invalid command")
+	// ErrInvalidSlot means the slot number is out of range or not configured
+	ErrInvalidSlot = errors.New("invalid slot number")
+	// ErrDataTooLong means the data doesn't fit in a slot
+	ErrDataTooLong = errors.New("data too long")
+	// ErrAuthRequired means the slot can't be used without authenticating
+	ErrAuthRequired = errors.New("authentication required")
+	// ErrInvalidCredentials means the server rejected the user or password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrPermissionDenied means the user can't run the command on the slot
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrSlotLocked means another user holds the slot
+	ErrSlotLocked = errors.New("slot locked")
+	// ErrNoTokens means a token bucket is empty
+	ErrNoTokens = errors.New("no tokens available")
+	// ErrServer means the server failed to run the command
+	ErrServer = errors.New("internal server error")
+
+	// ErrTimeout means the response didn't arrive in time
+	ErrTimeout = errors.New("timeout waiting for response")
+	// ErrCanceled means the caller cancelled the request
+	ErrCanceled = errors.New("request cancelled")
+	// ErrClosed means the client was closed
+	ErrClosed = errors.New("client closed")
+	// ErrConnectionLost means the connection dropped before the response arrived
+	ErrConnectionLost = errors.New("connection lost")
+	// ErrReconnecting means the client is waiting to get the connection back
+	ErrReconnecting = errors.New("client reconnecting")
+	// ErrInvalidResponse means the server sent something the client can't parse
+	ErrInvalidResponse = errors.New("invalid response")
+	// ErrInvalidConfig means a configuration value is not valid
+	ErrInvalidConfig = errors.New("invalid configuration")
+	// ErrInvalidValue means a value can't be encoded or decoded by the codec
+	// of its slot
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// codes maps every known error code to its sentinel
+var codes = map[string]error{
+	CodeInvalidCommand:     ErrInvalidCommand,
+	CodeInvalidSlot:        ErrInvalidSlot,
+	CodeInvalidData:        ErrDataTooLong,
+	CodeAuthRequired:       ErrAuthRequired,
+	CodeInvalidCredentials: ErrInvalidCredentials,
+	CodePermissionDenied:   ErrPermissionDenied,
+	CodeSlotLocked:         ErrSlotLocked,
+	CodeNoTokens:           ErrNoTokens,
+	CodeInternalError:      ErrServer,
+}
+
+// retryable lists the errors that may go away by trying again later
+var retryable = []error{
+	ErrSlotLocked,
+	ErrNoTokens,
+	ErrServer,
+	ErrTimeout,
+	ErrConnectionLost,
+	ErrReconnecting,
+}
+
+// Retryable tells if an operation that failed with err may succeed if it is
+// tried again later, such as timeouts, lost connections or empty buckets
+func Retryable(err error) bool {
+	for _, target := range retryable {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// CodeOf returns the error code the server sends for err, which is either a
+// GhotiError or wraps one of the sentinels with a code
+func CodeOf(err error) (string, bool) {
+	var ghotiErr *GhotiError
+	if errors.As(err, &ghotiErr) {
+		return ghotiErr.Code, true
+	}
+
+	for code, sentinel := range codes {
+		if errors.Is(err, sentinel) {
+			return code, true
+		}
+	}
+	return "", false
+}
 
 // GhotiError represents an error from the Ghoti server
 type GhotiError struct {
@@ -13,27 +120,38 @@ func (e *GhotiError) Error() string {
 	return fmt.Sprintf("Ghoti error %s: %s", e.Code, e.Message)
 }
 
+// Is makes errors.Is match the sentinel error of the code
+func (e *GhotiError) Is(target error) bool {
+	sentinel, ok := codes[e.Code]
+	return ok && sentinel == target
+}
+
+// Retryable tells if the command may succeed if it is sent again later
+func (e *GhotiError) Retryable() bool {
+	return Retryable(e)
+}
+
 // NewGhotiError creates a new GhotiError
 func NewGhotiError(code string) *GhotiError {
 	var message string
 	switch code {
-	case "001":
+	case CodeInvalidCommand:
 		message = "Invalid command"
-	case "002":
+	case CodeInvalidSlot:
 		message = "Invalid slot number"
-	case "003":
+	case CodeInvalidData:
 		message = "Invalid data length"
-	case "004":
+	case CodeAuthRequired:
 		message = "Authentication required"
-	case "005":
+	case CodeInvalidCredentials:
 		message = "Invalid credentials"
-	case "006":
+	case CodePermissionDenied:
 		message = "Permission denied"
-	case "007":
+	case CodeSlotLocked:
 		message = "Slot locked"
-	case "008":
+	case CodeNoTokens:
 		message = "No tokens available"
-	case "009":
+	case CodeInternalError:
 		message = "Internal server error"
 	default:
 		message = "Unknown error"
@@ -42,4 +160,4 @@ func NewGhotiError(code string) *GhotiError {
 		Code:    code,
 		Message: message,
 	}
-}
\ No newline at end of file
+}
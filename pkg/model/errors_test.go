@@ -0,0 +1,73 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGhotiErrorIs(t *testing.T) {
+	tests := map[string]error{
+		CodeInvalidCommand:     ErrInvalidCommand,
+		CodeInvalidSlot:        ErrInvalidSlot,
+		CodeInvalidData:        ErrDataTooLong,
+		CodeAuthRequired:       ErrAuthRequired,
+		CodeInvalidCredentials: ErrInvalidCredentials,
+		CodePermissionDenied:   ErrPermissionDenied,
+		CodeSlotLocked:         ErrSlotLocked,
+		CodeNoTokens:           ErrNoTokens,
+		CodeInternalError:      ErrServer,
+	}
+
+	for code, sentinel := range tests {
+		t.Run(code, func(t *testing.T) {
+			err := fmt.Errorf("wrapped: %w", NewGhotiError(code))
+			assert.ErrorIs(t, err, sentinel)
+			assert.NotErrorIs(t, err, ErrTimeout)
+		})
+	}
+
+	assert.False(t, errors.Is(NewGhotiError("999"), ErrServer))
+}
+
+func TestRetryable(t *testing.T) {
+	tests := map[string]struct {
+		err       error
+		retryable bool
+	}{
+		"slot locked":       {NewGhotiError(CodeSlotLocked), true},
+		"no tokens":         {NewGhotiError(CodeNoTokens), true},
+		"server error":      {NewGhotiError(CodeInternalError), true},
+		"timeout":           {fmt.Errorf("%w: deadline", ErrTimeout), true},
+		"connection lost":   {fmt.Errorf("%w: EOF", ErrConnectionLost), true},
+		"reconnecting":      {ErrReconnecting, true},
+		"permission denied": {NewGhotiError(CodePermissionDenied), false},
+		"data too long":     {ErrDataTooLong, false},
+		"closed":            {ErrClosed, false},
+		"canceled":          {ErrCanceled, false},
+		"nil":               {nil, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.retryable, Retryable(test.err))
+		})
+	}
+
+	assert.True(t, NewGhotiError(CodeNoTokens).Retryable())
+}
+
+func TestCodeOf(t *testing.T) {
+	code, ok := CodeOf(fmt.Errorf("%w: 1000", ErrInvalidSlot))
+	assert.True(t, ok)
+	assert.Equal(t, CodeInvalidSlot, code)
+
+	code, ok = CodeOf(fmt.Errorf("wrapped: %w", NewGhotiError("999")))
+	assert.True(t, ok)
+	assert.Equal(t, "999", code)
+
+	_, ok = CodeOf(ErrTimeout)
+	assert.False(t, ok)
+}
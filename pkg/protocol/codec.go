@@ -0,0 +1,133 @@
+package protocol
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+// Encoder writes commands and messages to a stream
+type Encoder struct {
+	w      io.Writer
+	buffer []byte
+}
+
+// NewEncoder returns an encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// EncodeCommand validates commands and writes them in a single write
+func (e *Encoder) EncodeCommand(commands ...Command) error {
+	e.buffer = e.buffer[:0]
+	for _, c := range commands {
+		var err error
+		if e.buffer, err = AppendCommand(e.buffer, c); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.w.Write(e.buffer)
+	return err
+}
+
+// EncodeMessage validates a message and writes it
+func (e *Encoder) EncodeMessage(m Message) error {
+	var err error
+	if e.buffer, err = AppendMessage(e.buffer[:0], m); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(e.buffer)
+	return err
+}
+
+// Decoder reads commands and messages from a stream. Empty lines are skipped
+// and lines may end with \r\n. Lines longer than MaxLineLength are skipped
+// with an error, so a peer can't make the decoder buffer without bound.
+type Decoder struct {
+	r      *bufio.Reader
+	buffer []byte
+}
+
+// NewDecoder returns a decoder reading from r, which is buffered unless it
+// is already a *bufio.Reader
+func NewDecoder(r io.Reader) *Decoder {
+	reader, ok := r.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(r)
+	}
+	return &Decoder{r: reader}
+}
+
+// DecodeCommand reads the next command. Errors reading the stream are
+// returned as is, invalid lines return the error of ParseCommand and the
+// decoder can go on with the next line.
+func (d *Decoder) DecodeCommand() (Command, error) {
+	line, err := d.line(model.ErrInvalidCommand)
+	if err != nil {
+		return Command{}, err
+	}
+	return ParseCommand(line)
+}
+
+// DecodeMessage reads the next message. Errors reading the stream are
+// returned as is, invalid lines return the error of ParseMessage and the
+// decoder can go on with the next line.
+func (d *Decoder) DecodeMessage() (Message, error) {
+	line, err := d.line(model.ErrInvalidResponse)
+	if err != nil {
+		return Message{}, err
+	}
+	return ParseMessage(line)
+}
+
+// line reads the next line that is not empty, without its line break. A line
+// longer than MaxLineLength is discarded and returns the invalid error.
+func (d *Decoder) line(invalid error) (string, error) {
+	for {
+		long, err := d.read()
+		if err != nil {
+			return "", err
+		}
+
+		line := strings.TrimSuffix(strings.TrimSuffix(string(d.buffer), "\n"), "\r")
+		if long || len(line) > MaxLineLength {
+			return "", fmt.Errorf("%w: line longer than %d characters", invalid, MaxLineLength)
+		}
+		if line != "" {
+			return line, nil
+		}
+	}
+}
+
+// read reads a line into the buffer, keeping at most MaxLineLength
+// characters and the line break. It tells if the rest was discarded.
+func (d *Decoder) read() (bool, error) {
+	d.buffer = d.buffer[:0]
+	long := false
+	for {
+		chunk, err := d.r.ReadSlice('\n')
+		if !long {
+			d.buffer = append(d.buffer, chunk...)
+			long = len(d.buffer) > MaxLineLength+len("\r\n")
+		}
+
+		switch err {
+		case nil:
+			return long, nil
+		case bufio.ErrBufferFull:
+		case io.EOF:
+			// A line cut by the end of the stream is not complete
+			if len(d.buffer) > 0 {
+				return false, io.ErrUnexpectedEOF
+			}
+			return false, io.EOF
+		default:
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWriter counts the calls to Write
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestEncoder(t *testing.T) {
+	var w countingWriter
+	encoder := NewEncoder(&w)
+
+	require.NoError(t, encoder.EncodeCommand(Read(1), Write(2, "two"), User("service")))
+	assert.Equal(t, "r001\nw002two\nuservice\n", w.String())
+	assert.Equal(t, 1, w.writes, "commands are sent in a single write")
+
+	// Nothing is written if a command is not valid
+	err := encoder.EncodeCommand(Read(3), Read(1000))
+	assert.True(t, errors.Is(err, model.ErrInvalidSlot))
+	assert.Equal(t, 1, w.writes)
+
+	w.Reset()
+	require.NoError(t, encoder.EncodeMessage(SlotValue(1, "one")))
+	require.NoError(t, encoder.EncodeMessage(Error(model.CodeAuthRequired)))
+	require.NoError(t, encoder.EncodeMessage(Broadcast(7, "hi")))
+	assert.Equal(t, "v001one\ne004\na007hi\n", w.String())
+
+	err = encoder.EncodeMessage(Error("x"))
+	assert.True(t, errors.Is(err, model.ErrInvalidResponse))
+}
+
+func TestDecoder(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader("r001\n\nw002two\r\nx\nuservice"))
+
+	cmd, err := decoder.DecodeCommand()
+	require.NoError(t, err)
+	assert.Equal(t, Read(1), cmd)
+
+	// Empty lines are skipped and \r\n is accepted
+	cmd, err = decoder.DecodeCommand()
+	require.NoError(t, err)
+	assert.Equal(t, Write(2, "two"), cmd)
+
+	// An invalid line doesn't stop the decoder
+	_, err = decoder.DecodeCommand()
+	assert.True(t, errors.Is(err, model.ErrInvalidCommand))
+
+	// The last line has no line break
+	_, err = decoder.DecodeCommand()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = decoder.DecodeCommand()
+	assert.Equal(t, io.EOF, err)
+
+	decoder = NewDecoder(strings.NewReader("v005data\ne002\na001hi\n"))
+	for _, expected := range []Message{SlotValue(5, "data"), Error(model.CodeInvalidSlot), Broadcast(1, "hi")} {
+		message, err := decoder.DecodeMessage()
+		require.NoError(t, err)
+		assert.Equal(t, expected, message)
+	}
+	_, err = decoder.DecodeMessage()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderLongLines(t *testing.T) {
+	long := strings.Repeat("x", MaxLineLength+1)
+	longest := "u" + strings.Repeat("x", MaxLineLength-1)
+
+	// Lines longer than the buffer of the reader are still read whole
+	stream := "r001\n" + long + "\n" + longest + "\r\nr002\n" + long
+	decoder := NewDecoder(bufio.NewReaderSize(strings.NewReader(stream), 16))
+
+	cmd, err := decoder.DecodeCommand()
+	require.NoError(t, err)
+	assert.Equal(t, Read(1), cmd)
+
+	// A line too long is skipped, and the decoder goes on with the next one
+	_, err = decoder.DecodeCommand()
+	assert.True(t, errors.Is(err, model.ErrInvalidCommand), "got %v", err)
+
+	cmd, err = decoder.DecodeCommand()
+	require.NoError(t, err)
+	assert.Equal(t, User(longest[1:]), cmd)
+
+	cmd, err = decoder.DecodeCommand()
+	require.NoError(t, err)
+	assert.Equal(t, Read(2), cmd)
+
+	_, err = decoder.DecodeCommand()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	decoder = NewDecoder(strings.NewReader("v" + long + "\nv001one\n"))
+	_, err = decoder.DecodeMessage()
+	assert.True(t, errors.Is(err, model.ErrInvalidResponse), "got %v", err)
+
+	message, err := decoder.DecodeMessage()
+	require.NoError(t, err)
+	assert.Equal(t, SlotValue(1, "one"), message)
+}
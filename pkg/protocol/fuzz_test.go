@@ -0,0 +1,100 @@
+package protocol
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func FuzzParseCommand(f *testing.F) {
+	for _, line := range []string{"r005", "w999hello", "w000", "uservice", "psecret", "r05", "x", "w001\r", ""} {
+		f.Add(line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		cmd, err := ParseCommand(line)
+		if err != nil {
+			return
+		}
+
+		// A valid command is encoded back to the same line
+		if err := cmd.Validate(); err != nil {
+			t.Fatalf("parsed command %q is not valid: %v", line, err)
+		}
+		if cmd.String() != line {
+			t.Fatalf("command %q encoded as %q", line, cmd.String())
+		}
+	})
+}
+
+func FuzzParseMessage(f *testing.F) {
+	for _, line := range []string{"v005data", "vservice", "e002", "e0021", "a001hi", "a01", "eabc", ""} {
+		f.Add(line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		message, err := ParseMessage(line)
+		if err != nil {
+			return
+		}
+
+		if err := message.Validate(); err != nil {
+			t.Fatalf("parsed message %q is not valid: %v", line, err)
+		}
+
+		// Anything after the code of an error is dropped
+		again, err := ParseMessage(message.String())
+		if err != nil || again != message {
+			t.Fatalf("message %q encoded as %q", line, message.String())
+		}
+	})
+}
+
+func FuzzDecoder(f *testing.F) {
+	f.Add("r001\nw002two\r\n\nuservice\n")
+	f.Add("v005data\ne002\na001hi")
+	f.Add("r001\n" + strings.Repeat("x", MaxLineLength+1) + "\nr002\n")
+
+	f.Fuzz(func(t *testing.T, stream string) {
+		commands := NewDecoder(strings.NewReader(stream))
+		for i := 0; i <= len(stream); i++ {
+			_, err := commands.DecodeCommand()
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+		}
+
+		messages := NewDecoder(strings.NewReader(stream))
+		for i := 0; i <= len(stream); i++ {
+			_, err := messages.DecodeMessage()
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+		}
+	})
+}
+
+func FuzzValueCodecs(f *testing.F) {
+	f.Add([]byte("hello"))
+	f.Add([]byte("a\nb\\c\x00"))
+	f.Add([]byte{0, 0, 0, 0, 0xff})
+
+	f.Fuzz(func(t *testing.T, value []byte) {
+		for name, codec := range Codecs {
+			data, err := EncodeValue(codec, value)
+			if err != nil {
+				continue
+			}
+
+			// The data is a valid command and decodes to the same value
+			if err := Write(1, data).Validate(); err != nil {
+				t.Fatalf("%s encoded %q as %q: %v", name, value, data, err)
+			}
+			decoded, err := codec.Decode(data)
+			if err != nil || string(decoded) != string(value) {
+				t.Fatalf("%s decoded %q as %q: %v", name, value, decoded, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,310 @@
+// Package protocol encodes and decodes the lines of the Ghoti protocol.
+//
+// Clients send commands, one per line:
+//
+//	r005         read slot 5
+//	w005data     write data to slot 5
+//	uname        user to authenticate as
+//	psecret      password of the user
+//
+// The server answers every command in order with a value or an error, and
+// sends broadcasts at any time:
+//
+//	v005data     value, the slot and its data for slot commands
+//	e002         error with its code
+//	a005data     broadcast sent to slot 5
+package protocol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+const (
+	// MaxSlot is the highest slot number
+	MaxSlot = 999
+	// MaxDataLength is the maximum length of the data of a slot
+	MaxDataLength = 36
+	// MaxLineLength is the maximum length of a line without its line break,
+	// well above the longest slot command so user names and passwords fit
+	MaxLineLength = 1024
+)
+
+// Op is the operation of a command
+type Op byte
+
+const (
+	// OpRead reads a slot
+	OpRead Op = 'r'
+	// OpWrite writes a slot
+	OpWrite Op = 'w'
+	// OpUser sets the user to authenticate as
+	OpUser Op = 'u'
+	// OpPass authenticates with the password of the user
+	OpPass Op = 'p'
+)
+
+// Command is a line sent by a client
+type Command struct {
+	Op Op
+	// Slot is the slot of read and write commands
+	Slot int
+	// Data is the value of a write, the user name or the password
+	Data string
+}
+
+// Read returns the command reading a slot
+func Read(slot int) Command {
+	return Command{Op: OpRead, Slot: slot}
+}
+
+// Write returns the command writing data to a slot
+func Write(slot int, data string) Command {
+	return Command{Op: OpWrite, Slot: slot, Data: data}
+}
+
+// User returns the command setting the user to authenticate as
+func User(name string) Command {
+	return Command{Op: OpUser, Data: name}
+}
+
+// Pass returns the command sending the password of the user
+func Pass(pass string) Command {
+	return Command{Op: OpPass, Data: pass}
+}
+
+// Validate checks the command follows the rules of the protocol. The errors
+// wrap the sentinel the server would answer with, such as model.ErrInvalidSlot.
+func (c Command) Validate() error {
+	switch c.Op {
+	case OpRead, OpWrite:
+		if c.Slot < 0 || c.Slot > MaxSlot {
+			return fmt.Errorf("%w: %d", model.ErrInvalidSlot, c.Slot)
+		}
+		if c.Op == OpRead && c.Data != "" {
+			return fmt.Errorf("%w: read commands have no data", model.ErrInvalidCommand)
+		}
+		if len(c.Data) > MaxDataLength {
+			return fmt.Errorf("%w: maximum length is %d characters", model.ErrDataTooLong, MaxDataLength)
+		}
+	case OpUser, OpPass:
+		if len(c.Data) >= MaxLineLength {
+			return fmt.Errorf("%w: maximum line length is %d characters", model.ErrDataTooLong, MaxLineLength)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operation %q", model.ErrInvalidCommand, c.Op)
+	}
+
+	if strings.ContainsAny(c.Data, "\r\n") {
+		return fmt.Errorf("%w: line breaks are not allowed", model.ErrInvalidCommand)
+	}
+
+	return nil
+}
+
+// String returns the line of the command without the line break
+func (c Command) String() string {
+	switch c.Op {
+	case OpRead, OpWrite:
+		return fmt.Sprintf("%c%03d%s", c.Op, c.Slot, c.Data)
+	default:
+		return string(c.Op) + c.Data
+	}
+}
+
+// AppendCommand validates a command and appends its line, with the line
+// break, to dst
+func AppendCommand(dst []byte, c Command) ([]byte, error) {
+	if err := c.Validate(); err != nil {
+		return dst, err
+	}
+
+	dst = append(dst, c.String()...)
+	return append(dst, '\n'), nil
+}
+
+// ParseCommand parses a command line without its line break
+func ParseCommand(line string) (Command, error) {
+	if line == "" {
+		return Command{}, fmt.Errorf("%w: empty line", model.ErrInvalidCommand)
+	}
+
+	c := Command{Op: Op(line[0])}
+	switch c.Op {
+	case OpRead, OpWrite:
+		slot, err := parseSlot(line[1:])
+		if err != nil {
+			return Command{}, fmt.Errorf("%w: %q", model.ErrInvalidSlot, line)
+		}
+		c.Slot = slot
+		c.Data = line[4:]
+	case OpUser, OpPass:
+		c.Data = line[1:]
+	}
+
+	if err := c.Validate(); err != nil {
+		return Command{}, err
+	}
+
+	return c, nil
+}
+
+// Kind is the type of a message
+type Kind byte
+
+const (
+	// KindValue answers a command that succeeded
+	KindValue Kind = 'v'
+	// KindError answers a command that failed
+	KindError Kind = 'e'
+	// KindBroadcast is a broadcast sent to a slot
+	KindBroadcast Kind = 'a'
+)
+
+// Message is a line sent by the server
+type Message struct {
+	Kind Kind
+	// Slot is the slot of broadcasts
+	Slot int
+	// Code is the code of errors
+	Code string
+	// Data is the body of values, the slot and its data for slot commands
+	// or the user for authentication commands, and the data of broadcasts
+	Data string
+}
+
+// Value returns a value message with the given body
+func Value(body string) Message {
+	return Message{Kind: KindValue, Data: body}
+}
+
+// SlotValue returns the value message answering a command on a slot
+func SlotValue(slot int, data string) Message {
+	return Value(fmt.Sprintf("%03d%s", slot, data))
+}
+
+// Error returns an error message with the given code
+func Error(code string) Message {
+	return Message{Kind: KindError, Code: code}
+}
+
+// Broadcast returns a broadcast message sent to a slot
+func Broadcast(slot int, data string) Message {
+	return Message{Kind: KindBroadcast, Slot: slot, Data: data}
+}
+
+// Err returns the error sent by the server in error messages, or nil
+func (m Message) Err() error {
+	if m.Kind != KindError {
+		return nil
+	}
+	return model.NewGhotiError(m.Code)
+}
+
+// SlotData checks a value answers a command on the slot and returns its data
+func (m Message) SlotData(slot int) (string, error) {
+	if m.Kind != KindValue || len(m.Data) < 3 || m.Data[:3] != fmt.Sprintf("%03d", slot) {
+		return "", fmt.Errorf("%w: unexpected value format: %s", model.ErrInvalidResponse, m)
+	}
+	return m.Data[3:], nil
+}
+
+// Validate checks the message follows the rules of the protocol
+func (m Message) Validate() error {
+	switch m.Kind {
+	case KindValue:
+		if len(m.Data) >= MaxLineLength {
+			return fmt.Errorf("%w: value longer than the maximum line length of %d characters", model.ErrInvalidResponse, MaxLineLength)
+		}
+	case KindError:
+		if len(m.Code) != 3 || !digits(m.Code) {
+			return fmt.Errorf("%w: invalid error code %q", model.ErrInvalidResponse, m.Code)
+		}
+	case KindBroadcast:
+		if m.Slot < 0 || m.Slot > MaxSlot {
+			return fmt.Errorf("%w: invalid broadcast slot %d", model.ErrInvalidResponse, m.Slot)
+		}
+		if len(m.Data) > MaxDataLength {
+			return fmt.Errorf("%w: broadcast data longer than %d characters", model.ErrInvalidResponse, MaxDataLength)
+		}
+	default:
+		return fmt.Errorf("%w: unknown message type %q", model.ErrInvalidResponse, m.Kind)
+	}
+
+	if strings.ContainsAny(m.Data, "\r\n") {
+		return fmt.Errorf("%w: line breaks are not allowed", model.ErrInvalidResponse)
+	}
+
+	return nil
+}
+
+// String returns the line of the message without the line break
+func (m Message) String() string {
+	switch m.Kind {
+	case KindError:
+		return "e" + m.Code
+	case KindBroadcast:
+		return fmt.Sprintf("a%03d%s", m.Slot, m.Data)
+	default:
+		return string(m.Kind) + m.Data
+	}
+}
+
+// AppendMessage validates a message and appends its line, with the line
+// break, to dst
+func AppendMessage(dst []byte, m Message) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return dst, err
+	}
+
+	dst = append(dst, m.String()...)
+	return append(dst, '\n'), nil
+}
+
+// ParseMessage parses a message line without its line break
+func ParseMessage(line string) (Message, error) {
+	if line == "" {
+		return Message{}, fmt.Errorf("%w: empty line", model.ErrInvalidResponse)
+	}
+
+	m := Message{Kind: Kind(line[0])}
+	switch m.Kind {
+	case KindValue:
+		m.Data = line[1:]
+	case KindError:
+		// Anything after the code is ignored
+		if len(line) < 4 {
+			return Message{}, fmt.Errorf("%w: invalid error format: %s", model.ErrInvalidResponse, line)
+		}
+		m.Code = line[1:4]
+	case KindBroadcast:
+		slot, err := parseSlot(line[1:])
+		if err != nil {
+			return Message{}, fmt.Errorf("%w: invalid broadcast format: %s", model.ErrInvalidResponse, line)
+		}
+		m.Slot = slot
+		m.Data = line[4:]
+	}
+
+	if err := m.Validate(); err != nil {
+		return Message{}, err
+	}
+
+	return m, nil
+}
+
+// parseSlot parses the three digits of a slot at the start of s
+func parseSlot(s string) (int, error) {
+	if len(s) < 3 || !digits(s[:3]) {
+		return 0, fmt.Errorf("invalid slot")
+	}
+	return strconv.Atoi(s[:3])
+}
+
+// digits tells if s only has decimal digits
+func digits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
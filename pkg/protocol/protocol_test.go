@@ -0,0 +1,129 @@
+package protocol
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := map[string]struct {
+		line string
+		cmd  Command
+		err  error
+	}{
+		"read":              {line: "r005", cmd: Read(5)},
+		"write":             {line: "w999hello world", cmd: Write(999, "hello world")},
+		"write empty":       {line: "w000", cmd: Write(0, "")},
+		"user":              {line: "uservice", cmd: User("service")},
+		"pass":              {line: "psecret", cmd: Pass("secret")},
+		"empty user":        {line: "u", cmd: User("")},
+		"empty":             {line: "", err: model.ErrInvalidCommand},
+		"unknown":           {line: "x005", err: model.ErrInvalidCommand},
+		"read with data":    {line: "r005data", err: model.ErrInvalidCommand},
+		"short slot":        {line: "r05", err: model.ErrInvalidSlot},
+		"slot not a number": {line: "w0a5data", err: model.ErrInvalidSlot},
+		"signed slot":       {line: "r+05", err: model.ErrInvalidSlot},
+		"data too long":     {line: "w001" + strings.Repeat("x", 37), err: model.ErrDataTooLong},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cmd, err := ParseCommand(test.line)
+			if test.err != nil {
+				assert.True(t, errors.Is(err, test.err), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.cmd, cmd)
+			assert.Equal(t, test.line, cmd.String())
+		})
+	}
+}
+
+func TestCommandValidate(t *testing.T) {
+	tests := map[string]struct {
+		cmd Command
+		err error
+	}{
+		"read":          {cmd: Read(0)},
+		"longest data":  {cmd: Write(MaxSlot, strings.Repeat("x", MaxDataLength))},
+		"negative slot": {cmd: Read(-1), err: model.ErrInvalidSlot},
+		"slot too high": {cmd: Write(MaxSlot+1, "data"), err: model.ErrInvalidSlot},
+		"data too long": {cmd: Write(1, strings.Repeat("x", MaxDataLength+1)), err: model.ErrDataTooLong},
+		"line break":    {cmd: Write(1, "a\nb"), err: model.ErrInvalidCommand},
+		"password line": {cmd: Pass("secret\r"), err: model.ErrInvalidCommand},
+		"user too long": {cmd: User(strings.Repeat("x", MaxLineLength)), err: model.ErrDataTooLong},
+		"read data":     {cmd: Command{Op: OpRead, Slot: 1, Data: "x"}, err: model.ErrInvalidCommand},
+		"unknown op":    {cmd: Command{Op: 'x'}, err: model.ErrInvalidCommand},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.cmd.Validate()
+			if test.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, test.err), "got %v", err)
+
+			_, err = AppendCommand(nil, test.cmd)
+			assert.True(t, errors.Is(err, test.err))
+		})
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := map[string]struct {
+		line    string
+		message Message
+		invalid bool
+	}{
+		"slot value":        {line: "v005data", message: SlotValue(5, "data")},
+		"auth value":        {line: "vservice", message: Value("service")},
+		"empty value":       {line: "v", message: Value("")},
+		"error":             {line: "e007", message: Error(model.CodeSlotLocked)},
+		"broadcast":         {line: "a012hello", message: Broadcast(12, "hello")},
+		"empty":             {line: "", invalid: true},
+		"unknown":           {line: "x005", invalid: true},
+		"short error":       {line: "e01", invalid: true},
+		"error not a code":  {line: "eabc", invalid: true},
+		"short broadcast":   {line: "a01", invalid: true},
+		"broadcast too big": {line: "a001" + strings.Repeat("x", 37), invalid: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			message, err := ParseMessage(test.line)
+			if test.invalid {
+				assert.True(t, errors.Is(err, model.ErrInvalidResponse), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.message, message)
+			assert.Equal(t, test.line, message.String())
+		})
+	}
+}
+
+func TestMessageErr(t *testing.T) {
+	assert.NoError(t, Value("005data").Err())
+	assert.True(t, errors.Is(Error(model.CodeNoTokens).Err(), model.ErrNoTokens))
+}
+
+func TestMessageSlotData(t *testing.T) {
+	data, err := SlotValue(5, "data").SlotData(5)
+	require.NoError(t, err)
+	assert.Equal(t, "data", data)
+
+	_, err = SlotValue(5, "data").SlotData(6)
+	assert.True(t, errors.Is(err, model.ErrInvalidResponse))
+	_, err = Value("service").SlotData(5)
+	assert.True(t, errors.Is(err, model.ErrInvalidResponse))
+	_, err = Error(model.CodeInvalidSlot).SlotData(5)
+	assert.True(t, errors.Is(err, model.ErrInvalidResponse))
+}
@@ -0,0 +1,187 @@
+package protocol
+
+import (
+	"encoding/ascii85"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+)
+
+// ValueCodec turns arbitrary bytes into data that can be sent in a command
+// and back. The data it encodes has no line breaks or other characters that
+// are not printable.
+type ValueCodec interface {
+	// Name identifies the codec in errors and logs
+	Name() string
+	// Encode returns the data sent to the server for the value
+	Encode(value []byte) (string, error)
+	// Decode returns the value of the data stored in a slot
+	Decode(data string) ([]byte, error)
+}
+
+var (
+	// Text sends values as they are and rejects the ones with characters
+	// that are not printable ASCII, such as line breaks
+	Text ValueCodec = textCodec{}
+	// Escaped sends printable ASCII as it is and escapes everything else as
+	// \xNN, with backslashes sent as \\
+	Escaped ValueCodec = escapedCodec{}
+	// Hex sends values as hexadecimal, at most 18 bytes fit in a slot
+	Hex ValueCodec = hexCodec{}
+	// Base64 sends values as base64 without padding, at most 27 bytes fit in
+	// a slot
+	Base64 ValueCodec = base64Codec{}
+	// Base85 sends values as ascii85, at most 28 bytes fit in a slot
+	Base85 ValueCodec = base85Codec{}
+)
+
+// Codecs lists the value codecs by name
+var Codecs = map[string]ValueCodec{
+	Text.Name():    Text,
+	Escaped.Name(): Escaped,
+	Hex.Name():     Hex,
+	Base64.Name():  Base64,
+	Base85.Name():  Base85,
+}
+
+// EncodeValue encodes a value with the codec and checks the data fits in a slot
+func EncodeValue(codec ValueCodec, value []byte) (string, error) {
+	data, err := codec.Encode(value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > MaxDataLength {
+		return "", fmt.Errorf("%w: %d bytes are %d characters with %s, maximum length is %d characters",
+			model.ErrDataTooLong, len(value), len(data), codec.Name(), MaxDataLength)
+	}
+
+	return data, nil
+}
+
+// printable tells if c is printable ASCII
+func printable(c byte) bool {
+	return c >= ' ' && c <= '~'
+}
+
+// textCodec is the Text codec
+type textCodec struct{}
+
+func (textCodec) Name() string { return "text" }
+
+func (textCodec) Encode(value []byte) (string, error) {
+	for i, c := range value {
+		if !printable(c) {
+			return "", fmt.Errorf("%w: byte %d is %q, use a codec such as escaped or base64", model.ErrInvalidValue, i, c)
+		}
+	}
+	return string(value), nil
+}
+
+func (textCodec) Decode(data string) ([]byte, error) {
+	return []byte(data), nil
+}
+
+// escapedCodec is the Escaped codec
+type escapedCodec struct{}
+
+func (escapedCodec) Name() string { return "escaped" }
+
+func (escapedCodec) Encode(value []byte) (string, error) {
+	var data strings.Builder
+	for _, c := range value {
+		switch {
+		case c == '\\':
+			data.WriteString(`\\`)
+		case printable(c):
+			data.WriteByte(c)
+		default:
+			fmt.Fprintf(&data, `\x%02x`, c)
+		}
+	}
+	return data.String(), nil
+}
+
+func (escapedCodec) Decode(data string) ([]byte, error) {
+	value := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			value = append(value, data[i])
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(data[i:], `\\`):
+			value = append(value, '\\')
+			i++
+		case strings.HasPrefix(data[i:], `\x`) && len(data) >= i+4:
+			decoded, err := hex.DecodeString(data[i+2 : i+4])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid escape %q", model.ErrInvalidValue, data[i:i+4])
+			}
+			value = append(value, decoded[0])
+			i += 3
+		default:
+			return nil, fmt.Errorf("%w: invalid escape at character %d", model.ErrInvalidValue, i)
+		}
+	}
+	return value, nil
+}
+
+// hexCodec is the Hex codec
+type hexCodec struct{}
+
+func (hexCodec) Name() string { return "hex" }
+
+func (hexCodec) Encode(value []byte) (string, error) {
+	return hex.EncodeToString(value), nil
+}
+
+func (hexCodec) Decode(data string) ([]byte, error) {
+	value, err := hex.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidValue, err)
+	}
+	return value, nil
+}
+
+// base64Codec is the Base64 codec
+type base64Codec struct{}
+
+func (base64Codec) Name() string { return "base64" }
+
+func (base64Codec) Encode(value []byte) (string, error) {
+	return base64.RawStdEncoding.EncodeToString(value), nil
+}
+
+func (base64Codec) Decode(data string) ([]byte, error) {
+	value, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidValue, err)
+	}
+	return value, nil
+}
+
+// base85Codec is the Base85 codec
+type base85Codec struct{}
+
+func (base85Codec) Name() string { return "base85" }
+
+func (base85Codec) Encode(value []byte) (string, error) {
+	data := make([]byte, ascii85.MaxEncodedLen(len(value)))
+	n := ascii85.Encode(data, value)
+	return string(data[:n]), nil
+}
+
+func (base85Codec) Decode(data string) ([]byte, error) {
+	// Every 5 characters decode to at most 4 bytes, z decodes 1 to 4
+	value := make([]byte, 4*len(data))
+	n, _, err := ascii85.Decode(value, []byte(data), true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidValue, err)
+	}
+	return value[:n], nil
+}
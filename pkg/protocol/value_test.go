@@ -0,0 +1,106 @@
+package protocol
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fran150/ghoti-sdk-go-v1/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCodecs(t *testing.T) {
+	tests := map[string]struct {
+		codec ValueCodec
+		value string
+		data  string
+	}{
+		"text":            {codec: Text, value: "hello world", data: "hello world"},
+		"escaped":         {codec: Escaped, value: "a\nb\\c\x00", data: `a\x0ab\\c\x00`},
+		"escaped utf8":    {codec: Escaped, value: "ñ", data: `\xc3\xb1`},
+		"hex":             {codec: Hex, value: "\x00\xff\n", data: "00ff0a"},
+		"base64":          {codec: Base64, value: "\x00\xff\n", data: "AP8K"},
+		"base85":          {codec: Base85, value: "\x00\x00\x00\x00\n", data: "z$3"},
+		"empty text":      {codec: Text, value: "", data: ""},
+		"empty base85":    {codec: Base85, value: "", data: ""},
+		"empty base64":    {codec: Base64, value: "", data: ""},
+		"escaped spacing": {codec: Escaped, value: "a b\t", data: `a b\x09`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := EncodeValue(test.codec, []byte(test.value))
+			require.NoError(t, err)
+			assert.Equal(t, test.data, data)
+
+			value, err := test.codec.Decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, test.value, string(value))
+		})
+	}
+}
+
+func TestValueCodecLimits(t *testing.T) {
+	tests := map[string]struct {
+		codec ValueCodec
+		max   int
+	}{
+		"text":    {codec: Text, max: 36},
+		"escaped": {codec: Escaped, max: 36},
+		"hex":     {codec: Hex, max: 18},
+		"base64":  {codec: Base64, max: 27},
+		"base85":  {codec: Base85, max: 28},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			value := []byte(strings.Repeat("x", test.max))
+			_, err := EncodeValue(test.codec, value)
+			assert.NoError(t, err)
+
+			_, err = EncodeValue(test.codec, append(value, 'x'))
+			assert.True(t, errors.Is(err, model.ErrDataTooLong), "got %v", err)
+		})
+	}
+
+	// Escaped bytes take four characters
+	_, err := EncodeValue(Escaped, []byte(strings.Repeat("\n", 10)))
+	assert.True(t, errors.Is(err, model.ErrDataTooLong))
+}
+
+func TestValueCodecErrors(t *testing.T) {
+	for _, value := range []string{"a\nb", "a\rb", "\x00", "ñ"} {
+		_, err := EncodeValue(Text, []byte(value))
+		assert.True(t, errors.Is(err, model.ErrInvalidValue), "%q: got %v", value, err)
+	}
+
+	tests := map[string]struct {
+		codec ValueCodec
+		data  string
+	}{
+		"escaped unknown": {codec: Escaped, data: `a\nb`},
+		"escaped short":   {codec: Escaped, data: `\x0`},
+		"escaped hex":     {codec: Escaped, data: `\xzz`},
+		"escaped end":     {codec: Escaped, data: `a\`},
+		"hex odd":         {codec: Hex, data: "abc"},
+		"hex digits":      {codec: Hex, data: "zz"},
+		"base64":          {codec: Base64, data: "A"},
+		"base64 padding":  {codec: Base64, data: "AP8K=="},
+		"base85":          {codec: Base85, data: "~~~"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := test.codec.Decode(test.data)
+			assert.True(t, errors.Is(err, model.ErrInvalidValue), "got %v", err)
+		})
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range Codecs {
+		assert.Equal(t, name, codec.Name())
+	}
+	assert.Len(t, Codecs, 5)
+}